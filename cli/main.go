@@ -0,0 +1,330 @@
+package main
+
+import (
+	"GoSeek/config"
+	"GoSeek/internal/grep"
+	"GoSeek/internal/indexer"
+	"GoSeek/internal/locate"
+	"GoSeek/internal/querylang"
+	"GoSeek/internal/search"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Command line search over the indexes created by the app
+// it reads indexes.txt so it runs from the same folder as GoSeek
+//
+//	go run ./cli -q "error AND timeout" -folders project/logs
+//	go run ./cli -grep -q "func \\w+Handler\\(" -ext .go
+//	go run ./cli -similar -q "how to renew a passport"
+//	go run ./cli -like /home/me/docs/design.md
+//	go run ./cli -complete -q "error AND time"
+//	go run ./cli -duplicates -near -folders shared
+func main() {
+	queryString := flag.String("q", "", "query to search for, see -syntax")
+	syntax := flag.Bool("syntax", false, "print the query syntax")
+	folders := flag.String("folders", "", "comma separated folders to search in with their sub folders, all indexes if empty")
+	exclude := flag.String("exclude", "", "comma separated folders left out with their sub folders")
+	limit := flag.Int("n", 20, "results per page")
+	from := flag.Int("from", 0, "offset of the first result to print")
+	sortBy := flag.String("sort", "score", "sort by score, mod_time, size, name or path")
+	desc := flag.Bool("desc", false, "sort in descending order, scores are always best first")
+	minSize := flag.String("min-size", "", "smallest file size, like 10KB")
+	maxSize := flag.String("max-size", "", "biggest file size, like 1.5MB")
+	after := flag.String("after", "", "modified after a date or a relative time, like 2024-01-31 or \"last 7 days\"")
+	before := flag.String("before", "", "modified before a date or a relative time")
+	exts := flag.String("ext", "", "comma separated extensions, like .go,.md")
+	paths := flag.String("path", "", "comma separated path prefixes")
+	namesOnly := flag.Bool("names", false, "match the file names only")
+	complete := flag.Bool("complete", false, "print the completions of the last word of the query")
+	duplicates := flag.Bool("duplicates", false, "print the groups of copies among the indexed files instead of searching")
+	near := flag.Bool("near", false, "group the near copies with -duplicates too, not only the exact ones")
+	collapse := flag.Bool("collapse", false, "show every group of copies once in the results")
+	like := flag.String("like", "", "path of an indexed file, finds the documents like it instead of a query")
+	similarMode := flag.Bool("similar", false, "rank by similar meaning in the indexes with embeddings")
+	blend := flag.Float64("blend", -1, "weight of the word matches in -similar, 0 for the nearest documents only, the config value if negative")
+	nameBoost := flag.Float64("name-boost", 0, "weight of file name matches, the config value if 0")
+	locateName := flag.Bool("locate", false, "find files by name, indexed or not, instead of searching the content")
+	grepMode := flag.Bool("grep", false, "scan the contents with the query as a regular expression, prints path:line:offset:line")
+	ignoreCase := flag.Bool("i", false, "case insensitive -grep")
+	locateMode := flag.String("mode", "auto", "name matching of -locate: auto, prefix, substring or fuzzy")
+	facets := flag.Bool("facets", false, "print the counts by extension, folder, date and size")
+	byMonth := flag.Bool("by-month", false, "count the dates by month instead of year")
+	snippets := flag.Bool("snippets", true, "print the matches in context when the index stores content")
+	color := flag.Bool("color", true, "highlight the matches with terminal colors")
+	flag.Parse()
+	if *syntax {
+		fmt.Println(querylang.Syntax)
+		return
+	}
+	if *queryString == "" {
+		*queryString = strings.Join(flag.Args(), " ")
+	}
+	if *queryString == "" && *like == "" && !*duplicates {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !validSort(*sortBy) {
+		fmt.Fprintf(os.Stderr, "Unknown sort field %q\n", *sortBy)
+		os.Exit(2)
+	}
+	filter, err := parseFilter(*minSize, *maxSize, *after, *before)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	filter.Extensions = search.ParseList(*exts)
+	filter.PathPrefixes = search.ParseList(*paths)
+
+	engine, err := openIndexes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening indexes: %v\n", err)
+		os.Exit(1)
+	}
+	if *complete {
+		completions, err := engine.Complete(*queryString, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error completing: %v\n", err)
+			os.Exit(1)
+		}
+		for _, c := range completions {
+			fmt.Println(c)
+		}
+		return
+	}
+	if *duplicates {
+		dreq := &search.DuplicatesRequest{Near: *near, ExcludeFolders: search.ParseList(*exclude)}
+		if *folders != "" {
+			dreq.Folders = strings.Split(*folders, ",")
+		}
+		groups, err := engine.Duplicates(dreq)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding duplicates: %v\n", err)
+			os.Exit(1)
+		}
+		printDuplicates(groups)
+		return
+	}
+	if *locateName {
+		mode, ok := locate.ParseMode(*locateMode)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown name matching %q\n", *locateMode)
+			os.Exit(2)
+		}
+		matches, err := engine.Locate(*queryString, mode, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error locating: %v\n", err)
+			os.Exit(1)
+		}
+		for _, m := range matches {
+			fmt.Println(m.Path)
+		}
+		return
+	}
+	if *grepMode {
+		pattern, err := grep.Compile(*queryString, *ignoreCase)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid expression: %v\n", err)
+			os.Exit(2)
+		}
+		greq := &search.GrepRequest{Pattern: pattern, Filter: filter}
+		if *folders != "" {
+			greq.Folders = strings.Split(*folders, ",")
+		}
+		greq.ExcludeFolders = search.ParseList(*exclude)
+		stats, err := engine.Grep(context.Background(), greq, func(m grep.Match) bool {
+			fmt.Printf("%s:%d:%d:%s\n", m.Path, m.Line, m.Offset, formatMatch(m, *color))
+			return true
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "%d matches in %d files, %d of %d candidate files scanned\n",
+			stats.Matches, stats.Files, stats.Scanned, stats.Candidates)
+		return
+	}
+	req := &search.Request{
+		Query:      *queryString,
+		Highlight:  *snippets,
+		Filter:     filter,
+		From:       *from,
+		Size:       *limit,
+		SortBy:     search.SortField(*sortBy),
+		Descending: *desc,
+		Facets:     *facets,
+		NamesOnly:  *namesOnly,
+		Similar:    *similarMode,
+		Blend:      *blend,
+		Like:       *like,
+		Collapse:   *collapse,
+	}
+	if req.Blend < 0 {
+		req.Blend = config.LoadGlobalConfig().SimilarBlend
+	}
+	if *nameBoost > 0 {
+		engine.NameBoost = *nameBoost
+	}
+	if *byMonth {
+		req.FacetDates = search.ByMonth
+	}
+	if *folders != "" {
+		req.Folders = strings.Split(*folders, ",")
+	}
+	req.ExcludeFolders = search.ParseList(*exclude)
+	res, err := engine.Search(req)
+	var syntaxErr *querylang.Error
+	if errors.As(err, &syntaxErr) {
+		fmt.Fprintf(os.Stderr, "%s\nInvalid query at %v\n", syntaxErr.Caret(), err)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error searching: %v\n", err)
+		os.Exit(1)
+	}
+
+	printFacets(res.Facets)
+	if len(res.Hits) == 0 {
+		fmt.Printf("%d results\n", res.Total)
+		if res.DidYouMean != "" {
+			fmt.Printf("Did you mean: %s\n", res.DidYouMean)
+		}
+		return
+	}
+	fmt.Printf("%d-%d of %d results\n", req.From+1, req.From+len(res.Hits), res.Total)
+	for _, doc := range res.Hits {
+		fmt.Printf("%s  (%.2f)\n", doc.Path, doc.Score)
+		if meta := search.Metadata(doc, false); len(meta) > 0 {
+			fmt.Printf("    %s\n", strings.Join(meta, "  "))
+		}
+		for _, path := range doc.Copies {
+			fmt.Printf("    copy: %s\n", path)
+		}
+		for _, fragment := range doc.Fragments {
+			fmt.Printf("    %s\n", formatFragment(fragment, *color))
+		}
+	}
+}
+
+func printFacets(facets []search.Facet) {
+	for _, facet := range facets {
+		if len(facet.Buckets) == 0 {
+			continue
+		}
+		fmt.Printf("%s:", facet.Name)
+		for _, b := range facet.Buckets {
+			fmt.Printf("  %s", b)
+		}
+		if facet.Other > 0 {
+			fmt.Printf("  other (%d)", facet.Other)
+		}
+		fmt.Println()
+	}
+}
+
+func printDuplicates(groups []search.DuplicateGroup) {
+	var wasted int64
+	for _, g := range groups {
+		kind := "near copies"
+		if g.Exact {
+			kind = "copies"
+		}
+		fmt.Printf("%d %s, %s wasted\n", len(g.Files), kind, search.FormatSize(g.Wasted))
+		for _, f := range g.Files {
+			fmt.Printf("    %s  (%s)\n", f.Path, search.FormatSize(f.Size))
+		}
+		wasted += g.Wasted
+	}
+	fmt.Printf("%d groups, %s wasted\n", len(groups), search.FormatSize(wasted))
+}
+
+func parseFilter(minSize, maxSize, after, before string) (search.Filter, error) {
+	var filter search.Filter
+	var err error
+	if filter.MinSize, err = search.ParseSize(minSize); err != nil {
+		return filter, err
+	}
+	if filter.MaxSize, err = search.ParseSize(maxSize); err != nil {
+		return filter, err
+	}
+	now := time.Now()
+	if filter.After, err = search.ParseTime(after, now); err != nil {
+		return filter, err
+	}
+	if filter.Before, err = search.ParseTime(before, now); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func validSort(field string) bool {
+	for _, f := range search.SortFields {
+		if string(f) == field {
+			return true
+		}
+	}
+	return false
+}
+
+// openIndexes opens every index listed in indexes.txt
+func openIndexes() (*search.Engine, error) {
+	data, err := config.ReadFromFile()
+	if err != nil {
+		return nil, err
+	}
+	engine := search.NewEngine()
+	for _, path := range strings.Split(data, "\n") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		index := indexer.OpenBleve("index/" + filepath.Base(path))
+		if index == nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: index can not be opened\n", path)
+			continue
+		}
+		// fields of the current schema would be missing from it
+		if index.Outdated() {
+			fmt.Fprintf(os.Stderr, "Skipping %s: index made with schema version %d, open GoSeek to rebuild it\n", path, index.SchemaVersion())
+			index.Close()
+			continue
+		}
+		engine.Add(filepath.Base(path), index)
+	}
+	return engine, nil
+}
+
+// formatMatch is the line of a grep match with the match highlighted
+func formatMatch(m grep.Match, color bool) string {
+	start := m.Column - 1
+	end := start + m.Length
+	if !color || m.Length == 0 || end > len(m.Text) {
+		return m.Text
+	}
+	return m.Text[:start] + "\x1b[1;31m" + m.Text[start:end] + "\x1b[0m" + m.Text[end:]
+}
+
+var oneLine = strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ")
+
+// formatFragment puts a snippet on one line with the matches highlighted
+func formatFragment(fragment string, color bool) string {
+	var sb strings.Builder
+	for _, part := range search.SplitFragment(fragment) {
+		text := oneLine.Replace(part.Text)
+		switch {
+		case !part.Match:
+			sb.WriteString(text)
+		case color:
+			sb.WriteString("\x1b[1;33m" + text + "\x1b[0m")
+		default:
+			sb.WriteString("[" + text + "]")
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
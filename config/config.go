@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strings"
+	"time"
 )
 
 type GlobalConfig struct {
@@ -9,6 +11,40 @@ type GlobalConfig struct {
 	NumWorkers            int
 	IndexBatchMemoryLimit int32
 	ChannelBufferSize     int
+
+	// Resource governor limits shared by all indexes
+	MaxInFlightMemory int64         // content bytes read but not committed yet
+	ReadBandwidth     int64         // disk read bytes per second, 0 is unlimited
+	BackgroundReaders int           // readers allowed while the user is active or on battery
+	UserIdleTimeout   time.Duration // the user is active for this long after the last input
+	IndexingNiceness  int           // scheduling priority of the process, 0 keeps the default
+
+	// Search
+	NameBoost float64 // weight of file name matches against content matches
+	// SimilarBlend weighs the word matches of a similar meaning search
+	// against the nearest documents, 0 keeps only the nearest
+	SimilarBlend float64
+	// NearDuplicateBits are the bits the SimHashes of two near copies
+	// differ in at most, 0 finds the exact copies only
+	NearDuplicateBits int
+
+	// Files of the new indexes, by lower cased extension
+	TextExtensions     []string // read and indexed as text
+	DocumentExtensions []string // documents and photos, only their metadata is indexed
+
+	// Analyzers
+	CodeExtensions []string // source files split in identifier words when the index asks for it, indexed as text too
+
+	// Embeddings
+	// Embedder of the indexes created with embeddings, "hashing" needs no
+	// model, "wordvec:/path/to/vectors.txt" averages local word vectors
+	Embedder string
+
+	// Alerts of the saved searches run on the new and updated documents
+	AlertDesktop  bool          // show a desktop notification
+	AlertLog      string        // file the alerts are appended to, "-" for the standard output, empty for none
+	AlertWebhook  string        // local URL the alerts are posted to as JSON, empty for none
+	AlertCooldown time.Duration // a file matching again within it raises no new alert
 }
 
 // Global configs of the app
@@ -18,7 +54,41 @@ func LoadGlobalConfig() *GlobalConfig {
 		NumWorkers:            4,
 		IndexBatchMemoryLimit: 32 * 1024 * 1024,
 		ChannelBufferSize:     16,
+
+		MaxInFlightMemory: 512 * 1024 * 1024,
+		ReadBandwidth:     0,
+		BackgroundReaders: 1,
+		UserIdleTimeout:   30 * time.Second,
+		IndexingNiceness:  10,
+
+		NameBoost:    2,
+		SimilarBlend: 0.5,
+
+		NearDuplicateBits: 3,
+
+		TextExtensions:     []string{".txt", ".log", ".md", ".markdown", ".csv", ".json", ".xml", ".yaml", ".yml", ".html", ".htm"},
+		DocumentExtensions: []string{".pdf", ".docx", ".xlsx", ".pptx", ".odt", ".ods", ".odp", ".jpg", ".jpeg", ".tif", ".tiff"},
+
+		CodeExtensions: []string{".go", ".py", ".js", ".ts", ".java", ".c", ".h", ".cpp", ".cs", ".rs", ".rb", ".php", ".kt", ".swift"},
+
+		Embedder: "hashing",
+
+		AlertDesktop:  true,
+		AlertLog:      "-",
+		AlertWebhook:  "",
+		AlertCooldown: 5 * time.Minute,
+	}
+}
+
+// Extensions is the set of extensions the new indexes scan
+func (c *GlobalConfig) Extensions() map[string]bool {
+	extensions := make(map[string]bool)
+	for _, list := range [][]string{c.TextExtensions, c.DocumentExtensions, c.CodeExtensions} {
+		for _, ext := range list {
+			extensions[strings.ToLower(ext)] = true
+		}
 	}
+	return extensions
 }
 
 // use txt file as the presistent memory for now
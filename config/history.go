@@ -0,0 +1,182 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyFile keeps the past and saved searches next to indexes.txt
+const historyFile = "history.json"
+
+// MaxHistory is the number of past searches kept
+const MaxHistory = 100
+
+// SavedSearch is what is needed to run a search again
+// the filters are kept as shown in the filter bar
+type SavedSearch struct {
+	Name     string   `json:"name,omitempty"` // empty for the history
+	Query    string   `json:"query"`
+	Excluded []string `json:"excluded,omitempty"` // unchecked folders
+	Size     string   `json:"size,omitempty"`
+	Date     string   `json:"date,omitempty"`
+	Exts     string   `json:"exts,omitempty"`
+	Paths    string   `json:"paths,omitempty"`
+
+	NamesOnly bool `json:"names_only,omitempty"`
+	Similar   bool `json:"similar,omitempty"`
+	Collapse  bool `json:"collapse,omitempty"` // every group of copies shown once
+
+	Pinned  bool      `json:"pinned,omitempty"` // listed before the others
+	Alert   bool      `json:"alert,omitempty"`  // a standing query, new matching documents raise alerts
+	LastRun time.Time `json:"last_run"`
+}
+
+// same reports if both run the same search, whatever their names
+func (s SavedSearch) same(o SavedSearch) bool {
+	return s.Query == o.Query && slices.Equal(s.Excluded, o.Excluded) &&
+		s.Size == o.Size && s.Date == o.Date && s.Exts == o.Exts && s.Paths == o.Paths &&
+		s.NamesOnly == o.NamesOnly && s.Similar == o.Similar && s.Collapse == o.Collapse
+}
+
+// History of the searches, the last one first, and the saved ones
+type History struct {
+	Recent []SavedSearch `json:"recent"`
+	Saved  []SavedSearch `json:"saved"`
+	// Paused stops recording the searches, the saved ones still work
+	Paused bool `json:"paused,omitempty"`
+}
+
+// LoadHistory reads the searches of the previous sessions
+// a missing file is an empty history
+func LoadHistory() (*History, error) {
+	h := &History{}
+	data, err := os.ReadFile(historyFile)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return &History{}, err
+	}
+	return h, nil
+}
+
+// Save writes the history, readable by the user only
+func (h *History) Save() error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(historyFile, data, 0600)
+}
+
+// Record puts the search first in the history unless it is paused
+// the same search run before moves up instead of being repeated
+func (h *History) Record(s SavedSearch) {
+	if h.Paused || strings.TrimSpace(s.Query) == "" {
+		return
+	}
+	s.Name, s.Pinned, s.Alert = "", false, false
+	s.LastRun = time.Now()
+	h.Recent = slices.DeleteFunc(h.Recent, s.same)
+	h.Recent = slices.Insert(h.Recent, 0, s)
+	if len(h.Recent) > MaxHistory {
+		h.Recent = h.Recent[:MaxHistory]
+	}
+	// a saved search run again is the most recent one
+	for i := range h.Saved {
+		if h.Saved[i].same(s) {
+			h.Saved[i].LastRun = s.LastRun
+		}
+	}
+}
+
+// ClearHistory forgets the past searches, the saved ones are kept
+func (h *History) ClearHistory() {
+	h.Recent = nil
+}
+
+// SaveSearch keeps s under name, replacing the search of that name
+func (h *History) SaveSearch(name string, s SavedSearch) {
+	s.Name = name
+	for i := range h.Saved {
+		if h.Saved[i].Name == name {
+			s.Pinned, s.Alert = h.Saved[i].Pinned, h.Saved[i].Alert
+			h.Saved[i] = s
+			return
+		}
+	}
+	h.Saved = append(h.Saved, s)
+}
+
+// RemoveSearch deletes the saved search of that name
+func (h *History) RemoveSearch(name string) {
+	h.Saved = slices.DeleteFunc(h.Saved, func(s SavedSearch) bool { return s.Name == name })
+}
+
+// Pin lists the saved search first, or not
+func (h *History) Pin(name string, pinned bool) {
+	for i := range h.Saved {
+		if h.Saved[i].Name == name {
+			h.Saved[i].Pinned = pinned
+		}
+	}
+}
+
+// SetAlert makes the saved search of that name a standing query, or not
+func (h *History) SetAlert(name string, alert bool) {
+	for i := range h.Saved {
+		if h.Saved[i].Name == name {
+			h.Saved[i].Alert = alert
+		}
+	}
+}
+
+// Alerts are the saved searches run on the new documents
+func (h *History) Alerts() []SavedSearch {
+	var alerts []SavedSearch
+	for _, s := range h.Saved {
+		if s.Alert {
+			alerts = append(alerts, s)
+		}
+	}
+	return alerts
+}
+
+// SavedSearches are the pinned searches then the others, by name
+func (h *History) SavedSearches() []SavedSearch {
+	saved := slices.Clone(h.Saved)
+	sort.SliceStable(saved, func(i, j int) bool {
+		if saved[i].Pinned != saved[j].Pinned {
+			return saved[i].Pinned
+		}
+		return strings.ToLower(saved[i].Name) < strings.ToLower(saved[j].Name)
+	})
+	return saved
+}
+
+// Complete lists the queries of the saved and past searches
+// starting with prefix, case insensitive, the saved and recent ones first
+func (h *History) Complete(prefix string, limit int) []string {
+	prefix = strings.ToLower(prefix)
+	var queries []string
+	add := func(searches []SavedSearch) {
+		for _, s := range searches {
+			if len(queries) == limit {
+				return
+			}
+			if strings.HasPrefix(strings.ToLower(s.Query), prefix) && !slices.Contains(queries, s.Query) {
+				queries = append(queries, s.Query)
+			}
+		}
+	}
+	add(h.SavedSearches())
+	add(h.Recent)
+	return queries
+}
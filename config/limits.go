@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// limitsFile keeps the resource limits set by the user next to indexes.txt
+const limitsFile = "limits.json"
+
+// Limits of the background indexing the user can change
+// the values missing from the file are the ones of the global config
+type Limits struct {
+	MemoryMB          int64 `json:"memory_mb"`          // content read but not committed yet
+	ReadMBPerSec      int64 `json:"read_mb_per_sec"`    // disk read bandwidth, 0 is unlimited
+	Readers           int   `json:"readers"`            // concurrent readers while the machine is idle
+	BackgroundReaders int   `json:"background_readers"` // concurrent readers while the user is active or on battery
+	Niceness          int   `json:"niceness"`           // scheduling priority of the process, 0 keeps the default
+}
+
+// LoadLimits reads the limits of the user
+func LoadLimits() (*Limits, error) {
+	cfg := LoadGlobalConfig()
+	l := &Limits{
+		MemoryMB:          cfg.MaxInFlightMemory / (1024 * 1024),
+		ReadMBPerSec:      cfg.ReadBandwidth / (1024 * 1024),
+		Readers:           cfg.NumWorkers,
+		BackgroundReaders: cfg.BackgroundReaders,
+		Niceness:          cfg.IndexingNiceness,
+	}
+	data, err := os.ReadFile(limitsFile)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	return l, json.Unmarshal(data, l)
+}
+
+// Save writes the limits, they are used from the next start too
+func (l *Limits) Save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(limitsFile, data, 0644)
+}
@@ -0,0 +1,102 @@
+package gui
+
+import (
+	"GoSeek/internal/search"
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// duplicateRow is a line of the duplicates window,
+// the title of a group or one of its files
+type duplicateRow struct {
+	group *search.DuplicateGroup
+	file  int // index in the group, -1 for its title
+}
+
+// showDuplicates opens a window listing the groups of copies
+// in the checked folders, selecting a file shows it in the preview
+func (g *GUI) showDuplicates() {
+	w := g.app.NewWindow("Duplicates")
+	w.Resize(fyne.NewSize(DefaultWindowWidth, DefaultWindowHeight))
+
+	var rows []duplicateRow
+	status := widget.NewLabel("")
+	list := widget.NewList(
+		func() int { return len(rows) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			box := item.(*fyne.Container)
+			name := box.Objects[0].(*widget.Label)
+			dir := box.Objects[1].(*widget.Label)
+			row := rows[id]
+			if row.file < 0 {
+				kind := "near copies"
+				if row.group.Exact {
+					kind = "copies"
+				}
+				name.TextStyle.Bold = true
+				name.SetText(fmt.Sprintf("%d %s, %s wasted", len(row.group.Files), kind, search.FormatSize(row.group.Wasted)))
+				dir.Hide()
+				return
+			}
+			file := row.group.Files[row.file]
+			name.TextStyle.Bold = false
+			name.SetText(fmt.Sprintf("    %s  (%s)", filepath.Base(file.Path), search.FormatSize(file.Size)))
+			dir.SetText("    " + truncateText(filepath.Dir(file.Path), 80))
+			dir.Show()
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		if id < len(rows) && rows[id].file >= 0 {
+			row := rows[id]
+			g.loadPreview(row.group.Files[row.file].Path)
+		}
+	}
+
+	near := widget.NewCheck("Near copies", nil)
+	var find *widget.Button
+	find = widget.NewButtonWithIcon("Find", theme.SearchIcon(), func() {
+		g.noteUserActivity()
+		find.Disable()
+		status.SetText("Looking for copies...")
+		req := &search.DuplicatesRequest{
+			ExcludeFolders: g.getExcludedFolders(),
+			Near:           near.Checked,
+		}
+		go func() {
+			groups, err := searchEngine.Duplicates(req)
+			fyne.Do(func() {
+				find.Enable()
+				if err != nil {
+					status.SetText(err.Error())
+					return
+				}
+				rows = rows[:0]
+				var wasted int64
+				for i := range groups {
+					rows = append(rows, duplicateRow{group: &groups[i], file: -1})
+					for f := range groups[i].Files {
+						rows = append(rows, duplicateRow{group: &groups[i], file: f})
+					}
+					wasted += groups[i].Wasted
+				}
+				list.UnselectAll()
+				list.Refresh()
+				list.ScrollToTop()
+				status.SetText(fmt.Sprintf("%d groups, %s wasted", len(groups), search.FormatSize(wasted)))
+			})
+		}()
+	})
+	find.Importance = widget.HighImportance
+
+	top := container.NewHBox(near, find)
+	w.SetContent(container.NewBorder(top, status, nil, nil, list))
+	w.Show()
+}
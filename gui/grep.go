@@ -0,0 +1,123 @@
+package gui
+
+import (
+	"GoSeek/internal/grep"
+	"GoSeek/internal/search"
+	"context"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxGrepMatches stops a regex search flooding the list
+const maxGrepMatches = 5000
+
+// showGrep opens a window scanning the contents with a regular expression
+// in the checked folders and with the filters of the main window
+// matches are listed as they are found, selecting one shows it in the preview
+func (g *GUI) showGrep() {
+	w := g.app.NewWindow("Regex Search in Contents")
+	w.Resize(fyne.NewSize(DefaultWindowWidth*3/2, DefaultWindowHeight*3/2))
+
+	var matches []grep.Match
+	var pattern *grep.Pattern
+	cancel := func() {}
+	status := widget.NewLabel("")
+	list := widget.NewList(
+		func() int { return len(matches) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			box := item.(*fyne.Container)
+			where := box.Objects[0].(*widget.Label)
+			line := box.Objects[1].(*widget.Label)
+			m := matches[id]
+			where.TextStyle.Bold = true
+			where.SetText(fmt.Sprintf("%s:%d  (byte %d)", truncateText(m.Path, 80), m.Line, m.Offset))
+			line.SetText(truncateText(strings.TrimSpace(oneLine.Replace(m.Text)), 120))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		if id >= len(matches) || pattern == nil {
+			return
+		}
+		// the preview counts the matches of the file from its start
+		nth := 0
+		for i := id - 1; i >= 0 && matches[i].Path == matches[id].Path; i-- {
+			nth++
+		}
+		g.showPreview(matches[id].Path, pattern.Regexp(), nth, -1)
+	}
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(`Regular expression, like func \w+Handler\(`)
+	matchCase := widget.NewCheck("Match case", nil)
+	matchCase.SetChecked(true)
+
+	run := func() {
+		g.noteUserActivity()
+		cancel()
+		p, err := grep.Compile(entry.Text, !matchCase.Checked)
+		if err != nil {
+			status.SetText(err.Error())
+			return
+		}
+		filter, err := g.currentFilter()
+		if err != nil {
+			status.SetText(err.Error())
+			return
+		}
+		pattern = p
+		matches = nil
+		list.UnselectAll()
+		list.Refresh()
+		status.SetText("Searching...")
+		ctx, stop := context.WithCancel(context.Background())
+		cancel = stop
+		req := &search.GrepRequest{
+			Pattern:        p,
+			ExcludeFolders: g.getExcludedFolders(),
+			Filter:         filter,
+			MaxMatches:     maxGrepMatches,
+		}
+		go func() {
+			stats, err := searchEngine.Grep(ctx, req, func(m grep.Match) bool {
+				fyne.Do(func() {
+					if ctx.Err() == nil {
+						matches = append(matches, m)
+						list.Refresh()
+					}
+				})
+				return true
+			})
+			fyne.Do(func() {
+				if ctx.Err() != nil {
+					return // a newer search or the window closed
+				}
+				if err != nil {
+					status.SetText(err.Error())
+					return
+				}
+				status.SetText(fmt.Sprintf("%d matches in %d files, %d of %d candidate files scanned",
+					stats.Matches, stats.Files, stats.Scanned, stats.Candidates))
+			})
+		}()
+	}
+	entry.OnSubmitted = func(string) { run() }
+	searchButton := widget.NewButtonWithIcon("Search", theme.SearchIcon(), run)
+	stopButton := widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() {
+		cancel()
+		status.SetText(fmt.Sprintf("Stopped, %d matches", len(matches)))
+	})
+	w.SetOnClosed(func() { cancel() })
+
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(matchCase, searchButton, stopButton), entry)
+	w.SetContent(container.NewBorder(top, status, nil, nil, list))
+	w.Canvas().Focus(entry)
+	w.Show()
+}
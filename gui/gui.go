@@ -1,9 +1,16 @@
 package gui
 
 import (
+	"GoSeek/config"
+	"GoSeek/internal/coordinator"
+	"GoSeek/internal/governor"
+	"GoSeek/internal/indexer"
 	"GoSeek/internal/models"
+	"GoSeek/internal/search"
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,8 +34,11 @@ const (
 	TableColumnWidth3   = 100  // ext
 	TableColumnWidth4   = 500  // File Path
 	TableColumnWidth5   = 200  // ModTime
+	TableColumnWidth6   = 500  // Snippet
 	LeftPanelOffset     = 0.25 // 25% for left panel
 	ResultsPreviewSplit = 0.5  // 50% for results, 50% for preview
+	FacetPanelOffset    = 0.15 // 15% of the results for the facets
+	SavedSearchesSplit  = 0.7  // 70% of the left panel for the folders
 )
 
 // Folder
@@ -54,19 +64,41 @@ type treeContext struct {
 type GUI struct {
 	app             fyne.App
 	window          fyne.Window
-	searchEntry     *widget.Entry
+	searchEntry     *widget.SelectEntry // completes the queries from the history
 	resultsTable    *widget.Table
 	previewPanel    *previewPanel
 	folderTree      *widget.Tree
 	tree            *treeContext
 	searchResults   []models.Document
 	searchTerms     []string
+	searchSymbols   []string        // definitions searched with symbol:
+	searchRequest   *search.Request // request of the loaded pages, nil without a search
+	searchTotal     uint64
+	searchMore      bool // the search has hits after the loaded pages
+	searchSeq       int  // only the results of the last search are shown
+	loadingMore     bool
+	resultsLabel    *widget.Label
+	didYouMean      *widget.Button // corrects a query without results
+	sortBy          search.SortField
+	sortDescending  bool
+	sizeFilter      *widget.Select
+	dateFilter      *widget.Select
+	extFilter       *widget.Entry
+	pathFilter      *widget.Entry
+	facetPanel      *fyne.Container
+	namesOnly       *widget.Check
+	similar         *widget.Check                 // search by meaning in the indexes with embeddings
+	collapse        *widget.Check                 // show every group of copies once
+	refinements     map[string]search.FacetBucket // facet buckets clicked by the user, by facet name
 	excludedFolders map[string]bool
 	isDarkTheme     bool
 	locations       map[int]location
 	currentMatch    int
 	matchLabel      *widget.Label
 	matchEntry      *widget.Entry
+	history         *config.History
+	savedList       *widget.List
+	savedSearches   []config.SavedSearch // as listed, the pinned ones first
 }
 
 func NewApp() *GUI {
@@ -80,8 +112,12 @@ func NewApp() *GUI {
 		app:         app,
 		window:      window,
 		isDarkTheme: false,
+		refinements: make(map[string]search.FacetBucket),
 	}
 
+	gui.loadHistory()
+	gui.startAlerts()
+
 	// Create main menu with proper action connections
 	window.SetMainMenu(gui.createMainMenu())
 
@@ -97,35 +133,66 @@ func (g *GUI) createMainMenu() *fyne.MainMenu {
 	newItem := fyne.NewMenuItem("New Index", func() {
 		g.createNewIndex()
 	})
+	locateItem := fyne.NewMenuItem("Find File by Name...", func() {
+		g.showLocate()
+	})
+	grepItem := fyne.NewMenuItem("Regex Search in Contents...", func() {
+		g.showGrep()
+	})
 	quitItem := fyne.NewMenuItem("Quit", func() {
 		g.app.Quit()
 	})
-	fileMenu := fyne.NewMenu("File", newItem, fyne.NewMenuItemSeparator(), quitItem)
+	fileMenu := fyne.NewMenu("File", newItem, locateItem, grepItem, fyne.NewMenuItemSeparator(), quitItem)
 
 	// View menu
 	themeItem := fyne.NewMenuItem("Toggle Theme", func() {
 		g.toggleTheme()
 	})
-	viewMenu := fyne.NewMenu("View", themeItem)
+	usageItem := fyne.NewMenuItem("Resource Usage", func() {
+		g.showResourceUsage()
+	})
+	limitsItem := fyne.NewMenuItem("Resource Limits...", func() {
+		g.showResourceLimits()
+	})
+	viewMenu := fyne.NewMenu("View", themeItem, usageItem, limitsItem)
+
+	// Search menu
+	saveItem := fyne.NewMenuItem("Save Current Search...", func() {
+		g.showSaveSearch()
+	})
+	duplicatesItem := fyne.NewMenuItem("Find Duplicates...", func() {
+		g.showDuplicates()
+	})
+	historyItem := fyne.NewMenuItem("History...", func() {
+		g.showHistory()
+	})
+	clearHistoryItem := fyne.NewMenuItem("Clear History", func() {
+		g.clearHistory(nil)
+	})
+	searchMenu := fyne.NewMenu("Search", saveItem, historyItem, duplicatesItem, fyne.NewMenuItemSeparator(), clearHistoryItem)
 
 	// Help menu
 	aboutItem := fyne.NewMenuItem("About", func() {
 		// TODO: Show about dialog
 	})
-	helpMenu := fyne.NewMenu("Help", aboutItem)
+	syntaxItem := fyne.NewMenuItem("Query Syntax", func() {
+		g.showQuerySyntax()
+	})
+	helpMenu := fyne.NewMenu("Help", syntaxItem, aboutItem)
 
-	return fyne.NewMainMenu(fileMenu, viewMenu, helpMenu)
+	return fyne.NewMainMenu(fileMenu, searchMenu, viewMenu, helpMenu)
 }
 
 func (g *GUI) setupUI() {
 
 	g.createFolderTree()
+	g.resultsLabel = widget.NewLabel("Search Results")
 	g.createResultsTable()
 	previewPanel := g.createPreviewPanel()
 	searchContainer := g.createSearchPanel()
 
 	headerRow := widget.NewTable(
-		func() (int, int) { return 1, 6 },
+		func() (int, int) { return 1, len(tableHeaders) },
 		func() fyne.CanvasObject {
 			label := widget.NewLabel("Header")
 			label.TextStyle.Bold = true
@@ -133,21 +200,28 @@ func (g *GUI) setupUI() {
 		},
 		func(id widget.TableCellID, cell fyne.CanvasObject) {
 			label := cell.(*widget.Label)
-			headers := []string{"File Name", "Score", "Size", "Ext", "File Path", "ModTime"}
-			if id.Col < len(headers) {
-				label.SetText(headers[id.Col])
+			if id.Col < len(tableHeaders) {
+				label.SetText(tableHeaders[id.Col])
 				label.Refresh()
 			}
 		},
 	)
 	g.setTableColumnWidths(headerRow)
 
+	g.facetPanel = container.NewVBox()
+	resultsSplit := container.NewHSplit(container.NewVScroll(g.facetPanel), g.resultsTable)
+	resultsSplit.SetOffset(FacetPanelOffset)
+
+	g.didYouMean = widget.NewButton("", nil)
+	g.didYouMean.Importance = widget.LowImportance
+	g.didYouMean.Hide()
+
 	resultsContainer := container.NewBorder(
-		widget.NewLabel("Search Results"),
+		container.NewHBox(g.resultsLabel, g.didYouMean),
 		nil,
 		nil,
 		nil,
-		g.resultsTable,
+		resultsSplit,
 	)
 
 	mainContent := container.NewVSplit(
@@ -175,14 +249,19 @@ func (g *GUI) setupUI() {
 		nil,
 		scrollTree,
 	)
+	leftSplit := container.NewVSplit(leftPanel, g.createSavedPanel())
+	leftSplit.SetOffset(SavedSearchesSplit)
 
 	mainSplit := container.NewHSplit(
-		leftPanel,
+		leftSplit,
 		centerPanel,
 	)
 	mainSplit.SetOffset(LeftPanelOffset)
 
 	g.window.SetContent(mainSplit)
+	g.window.Canvas().SetOnTypedKey(func(*fyne.KeyEvent) {
+		g.noteUserActivity()
+	})
 
 	g.initializeFolderTree()
 
@@ -191,6 +270,7 @@ func (g *GUI) setupUI() {
 func (g *GUI) updateSearchResults(results []models.Document) {
 	g.searchResults = results
 	g.resultsTable.Refresh()
+	g.resultsLabel.SetText(fmt.Sprintf("Search Results (%d of %d)", len(results), g.searchTotal))
 
 	if len(results) == 0 {
 		g.previewPanel.lines = [][]widget.RichTextSegment{
@@ -236,13 +316,22 @@ func (g *GUI) setTableColumnWidths(table *widget.Table) {
 	table.SetColumnWidth(3, TableColumnWidth3) // Ext
 	table.SetColumnWidth(4, TableColumnWidth4) // FilePath
 	table.SetColumnWidth(5, TableColumnWidth5) // ModTime
+	table.SetColumnWidth(6, TableColumnWidth6) // Snippet
 }
 
 func (g *GUI) clearSearch() {
 	g.searchEntry.SetText("")
 	g.searchResults = []models.Document{}
+	g.searchRequest = nil
+	g.searchTotal = 0
+	g.searchMore = false
+	clear(g.refinements)
+	g.updateFacets(nil)
 	g.resultsTable.Refresh()
+	g.resultsLabel.SetText("Search Results")
+	g.didYouMean.Hide()
 	g.searchTerms = []string{}
+	g.searchSymbols = nil
 
 	g.previewPanel.lines = [][]widget.RichTextSegment{
 		{&widget.TextSegment{Text: "Select a search result to view preview..."}},
@@ -251,9 +340,15 @@ func (g *GUI) clearSearch() {
 
 func (g *GUI) createSearchPanel() *fyne.Container {
 
-	g.searchEntry = widget.NewEntry()
+	g.searchEntry = widget.NewSelectEntry(nil)
 	g.searchEntry.SetPlaceHolder("Enter search terms...")
-	g.searchEntry.SetText("GoSeek")
+	// the session goes on from the last search
+	g.searchEntry.SetText(g.lastQuery())
+	g.completeQuery(g.searchEntry.Text)
+	g.searchEntry.OnChanged = func(text string) {
+		g.noteUserActivity()
+		g.completeQuery(text)
+	}
 	g.searchEntry.OnSubmitted = func(query string) {
 		g.performSearch()
 	}
@@ -267,15 +362,151 @@ func (g *GUI) createSearchPanel() *fyne.Container {
 		g.clearSearch()
 	})
 
+	g.namesOnly = widget.NewCheck("Names only", func(bool) {
+		if g.searchRequest != nil {
+			g.performSearch()
+		}
+	})
+
+	g.similar = widget.NewCheck("Similar meaning", func(bool) {
+		if g.searchRequest != nil {
+			g.performSearch()
+		}
+	})
+
+	g.collapse = widget.NewCheck("Hide copies", func(bool) {
+		if g.searchRequest != nil {
+			g.performSearch()
+		}
+	})
+
 	searchRow := container.NewBorder(nil, nil, nil,
 		container.NewHBox(
+			g.namesOnly,
+			g.similar,
+			g.collapse,
 			searchButton,
 			clearButton,
 		),
 		g.searchEntry,
 	)
 
-	return container.NewVBox(searchRow)
+	return container.NewVBox(searchRow, g.createFilterBar())
+}
+
+// modification dates of the filter bar, relative to now
+var dateFilters = []struct {
+	label string
+	since string
+}{
+	{"Any Time", ""},
+	{"Today", "today"},
+	{"Last 7 Days", "last 7 days"},
+	{"Last 30 Days", "last 30 days"},
+	{"Last Year", "last year"},
+}
+
+func (g *GUI) createFilterBar() *fyne.Container {
+	sizes := []string{"Any Size"}
+	for _, r := range search.SizeRanges {
+		sizes = append(sizes, r.Label)
+	}
+	g.sizeFilter = widget.NewSelect(sizes, nil)
+	g.sizeFilter.SetSelectedIndex(0)
+
+	dates := make([]string, len(dateFilters))
+	for i, f := range dateFilters {
+		dates[i] = f.label
+	}
+	g.dateFilter = widget.NewSelect(dates, nil)
+	g.dateFilter.SetSelectedIndex(0)
+
+	g.extFilter = widget.NewEntry()
+	g.extFilter.SetPlaceHolder("Extensions: .go, .md")
+	g.extFilter.OnSubmitted = func(string) {
+		g.performSearch()
+	}
+
+	g.pathFilter = widget.NewEntry()
+	g.pathFilter.SetPlaceHolder("Path starts with...")
+	g.pathFilter.OnSubmitted = func(string) {
+		g.performSearch()
+	}
+
+	// changing a range runs the current search again
+	refine := func(string) {
+		if g.searchRequest != nil {
+			g.performSearch()
+		}
+	}
+	g.sizeFilter.OnChanged = refine
+	g.dateFilter.OnChanged = refine
+
+	return container.NewGridWithColumns(4, g.sizeFilter, g.dateFilter, g.extFilter, g.pathFilter)
+}
+
+// currentFilter reads the filter bar
+func (g *GUI) currentFilter() (search.Filter, error) {
+	filter, err := savedFilter(g.currentSearch())
+	if err != nil {
+		return filter, err
+	}
+	for _, name := range facetNames {
+		if b, ok := g.refinements[name]; ok {
+			b.Refine(&filter)
+		}
+	}
+	return filter, nil
+}
+
+// facetNames are the facets of the sidebar in display order
+var facetNames = []string{
+	search.FacetExtension, search.FacetDir, search.FacetModTime, search.FacetSize,
+	search.FacetAuthor, search.FacetLanguage, search.FacetOwner,
+}
+
+var facetTitles = map[string]string{
+	search.FacetExtension: "Type",
+	search.FacetDir:       "Folder",
+	search.FacetModTime:   "Modified",
+	search.FacetSize:      "Size",
+	search.FacetAuthor:    "Author",
+	search.FacetLanguage:  "Language",
+	search.FacetOwner:     "Owner",
+}
+
+// updateFacets shows the counts of the results
+// clicking a bucket refines the search, clicking a refinement removes it
+func (g *GUI) updateFacets(facets []search.Facet) {
+	g.facetPanel.RemoveAll()
+	for _, name := range facetNames {
+		if b, ok := g.refinements[name]; ok {
+			button := widget.NewButtonWithIcon(facetTitles[name]+": "+b.Label, theme.ContentClearIcon(), func() {
+				delete(g.refinements, name)
+				g.performSearch()
+			})
+			g.facetPanel.Add(button)
+		}
+	}
+	for _, facet := range facets {
+		if len(facet.Buckets) == 0 {
+			continue
+		}
+		title := widget.NewLabel(facetTitles[facet.Name])
+		title.TextStyle.Bold = true
+		g.facetPanel.Add(title)
+		for _, b := range facet.Buckets {
+			name := facet.Name
+			button := widget.NewButton(b.String(), func() {
+				g.refinements[name] = b
+				g.performSearch()
+			})
+			button.Alignment = widget.ButtonAlignLeading
+			button.Importance = widget.LowImportance
+			g.facetPanel.Add(button)
+		}
+	}
+	g.facetPanel.Refresh()
 }
 func truncateText(text string, maxLen int) string {
 	if len(text) > maxLen {
@@ -283,27 +514,57 @@ func truncateText(text string, maxLen int) string {
 	}
 	return text
 }
+
+var tableHeaders = []string{"File Name", "Score", "Size", "Ext", "File Path", "ModTime", "Snippet"}
+
+const snippetColumn = 6
+
+// columnSort maps the columns that can be sorted by clicking their header
+var columnSort = map[int]search.SortField{
+	0: search.SortName,
+	1: search.SortScore,
+	2: search.SortSize,
+	4: search.SortPath,
+	5: search.SortModTime,
+}
+
+// loadAhead is how close to the last loaded row the next page is fetched
+const loadAhead = 20
+
 func (g *GUI) createResultsTable() {
 	g.resultsTable = widget.NewTable(
 		func() (int, int) {
 			// Add one row for the header
-			return len(g.searchResults) + 1, 6 // rows, columns
+			return len(g.searchResults) + 1, len(tableHeaders) // rows, columns
 		},
 		func() fyne.CanvasObject {
-			return widget.NewLabel("")
+			// the snippet column shows the matches highlighted
+			return newResultCell(g.showResultMenu)
 		},
 		func(id widget.TableCellID, cell fyne.CanvasObject) {
-			label := cell.(*widget.Label)
-			headers := []string{"File Name", "Score", "Size", "Ext", "File Path", "ModTime"}
+			rc := cell.(*resultCell)
+			rc.row = id.Row
+			stack := rc.content
+			label := stack.Objects[0].(*widget.Label)
+			snippet := stack.Objects[1].(*widget.RichText)
+			label.Show()
+			snippet.Hide()
 			if id.Row == 0 {
 				label.TextStyle.Bold = true
-				label.SetText(headers[id.Col])
+				label.SetText(g.headerText(id.Col))
 			} else {
 				label.TextStyle.Bold = false
+				if id.Row+loadAhead > len(g.searchResults) {
+					g.loadMoreResults()
+				}
 				result := g.searchResults[id.Row-1]
 				switch id.Col {
 				case 0:
-					label.SetText(truncateText(filepath.Base(result.Path), 30))
+					name := truncateText(filepath.Base(result.Path), 30)
+					if len(result.Copies) > 0 {
+						name = fmt.Sprintf("%s (+%d)", name, len(result.Copies))
+					}
+					label.SetText(name)
 				case 1:
 					label.SetText(fmt.Sprintf("%.2f", result.Score))
 				case 2:
@@ -313,7 +574,12 @@ func (g *GUI) createResultsTable() {
 				case 4:
 					label.SetText(truncateText(result.Path, 80))
 				case 5:
-					label.SetText(result.ModTime)
+					label.SetText(formatModTime(result.ModTime))
+				case snippetColumn:
+					label.Hide()
+					snippet.Segments = snippetSegments(result.Fragments)
+					snippet.Show()
+					snippet.Refresh()
 				}
 			}
 		},
@@ -322,6 +588,12 @@ func (g *GUI) createResultsTable() {
 	g.setTableColumnWidths(g.resultsTable)
 
 	g.resultsTable.OnSelected = func(id widget.TableCellID) {
+		g.noteUserActivity()
+		if id.Row == 0 {
+			g.resultsTable.UnselectAll()
+			g.sortByColumn(id.Col)
+			return
+		}
 		if id.Row > 0 && id.Row-1 < len(g.searchResults) {
 			result := g.searchResults[id.Row-1]
 			g.loadPreview(result.Path)
@@ -329,6 +601,98 @@ func (g *GUI) createResultsTable() {
 	}
 }
 
+var oneLine = strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ")
+
+// headerText shows the sort direction on the sorted column
+func (g *GUI) headerText(col int) string {
+	field, ok := columnSort[col]
+	if !ok || field != g.currentSort() {
+		return tableHeaders[col]
+	}
+	if field == search.SortScore || g.sortDescending {
+		return tableHeaders[col] + " ▼"
+	}
+	return tableHeaders[col] + " ▲"
+}
+
+func (g *GUI) currentSort() search.SortField {
+	if g.sortBy == "" {
+		return search.SortScore
+	}
+	return g.sortBy
+}
+
+// sortByColumn sorts by the column, clicking it again reverses the order
+// sizes and dates start with the biggest and newest
+func (g *GUI) sortByColumn(col int) {
+	field, ok := columnSort[col]
+	if !ok {
+		return
+	}
+	if field == g.currentSort() {
+		g.sortDescending = !g.sortDescending
+	} else {
+		g.sortBy = field
+		g.sortDescending = field == search.SortSize || field == search.SortModTime
+	}
+	g.resultsTable.Refresh()
+	switch {
+	case g.searchRequest == nil:
+	case g.searchRequest.Like != "":
+		g.showMoreLikeThis(g.searchRequest.Like)
+	default:
+		g.performSearch()
+	}
+}
+
+// loadMoreResults fetches the next page of the current search in the background
+func (g *GUI) loadMoreResults() {
+	req := g.searchRequest
+	if req == nil || g.loadingMore || !g.searchMore {
+		return
+	}
+	g.loadingMore = true
+	next := *req
+	next.From = len(g.searchResults)
+	next.Facets = false
+	go func() {
+		res, err := searchEngine.Search(&next)
+		fyne.Do(func() {
+			g.loadingMore = false
+			// a new search started meanwhile
+			if g.searchRequest != req {
+				return
+			}
+			if err != nil {
+				fmt.Printf("Error loading more results: %v\n", err)
+				return
+			}
+			g.searchTotal = res.Total
+			g.searchMore = res.More(&next)
+			g.updateSearchResults(append(g.searchResults, res.Hits...))
+		})
+	}()
+}
+
+// snippetSegments shows the first fragment of a result on one line
+// with the matched terms highlighted like in the preview
+func snippetSegments(fragments []string) []widget.RichTextSegment {
+	if len(fragments) == 0 {
+		return nil
+	}
+	var segments []widget.RichTextSegment
+	for _, part := range search.SplitFragment(fragments[0]) {
+		text := oneLine.Replace(part.Text)
+		style := widget.RichTextStyle{Inline: true}
+		if part.Match {
+			style.ColorName = fyne.ThemeColorName("warning")
+			style.TextStyle = fyne.TextStyle{Bold: true}
+		}
+		segments = append(segments, &widget.TextSegment{Text: text, Style: style})
+	}
+	return segments
+}
+
 func (g *GUI) createPreviewPanel() *fyne.Container {
 	g.previewPanel = &previewPanel{}
 
@@ -421,7 +785,13 @@ func (g *GUI) gotoMatch(idx int) {
 	g.previewPanel.previewList.ScrollTo(loc.rowId)
 }
 func (g *GUI) initTreeContext() {
-	root := GetIndexes()
+	root := GetIndexes(func() {
+		// the cache may hold the folders missing before
+		g.tree.treeCache = make(map[string]*Folder)
+		if g.folderTree != nil {
+			g.folderTree.Refresh()
+		}
+	})
 	g.tree = &treeContext{
 		root:      root,
 		treeCache: make(map[string]*Folder),
@@ -561,14 +931,18 @@ func (g *GUI) createFolderTree() {
 				// TODO : Modify if UID will be changed Or Remove if not
 				folderPath := g.getFolderPath(uid)
 
-				check.SetChecked(!g.excludedFolders[folderPath])
+				// the node may have shown another folder
+				check.OnChanged = nil
+				check.SetChecked(!g.isExcluded(folderPath))
+				// an unchecked folder leaves out its whole subtree
+				if g.parentExcluded(folderPath) {
+					check.Disable()
+				} else {
+					check.Enable()
+				}
 
 				check.OnChanged = func(checked bool) {
-					if checked {
-						delete(g.excludedFolders, folderPath)
-					} else {
-						g.excludedFolders[folderPath] = true
-					}
+					g.setExcluded(folderPath, !checked)
 				}
 			}
 		},
@@ -628,75 +1002,146 @@ func (g *GUI) showFolderContextMenu(folder *Folder) {
 	widget.ShowPopUpMenuAtPosition(menu, g.window.Canvas(), fyne.CurrentApp().Driver().AbsolutePositionForObject(g.folderTree))
 }
 
-func (g *GUI) getCheckedFolders() []string {
-	var checkedFolders []string
+// getExcludedFolders are the unchecked folders, each one
+// leaves out its sub folders too
+func (g *GUI) getExcludedFolders() []string {
+	var excluded []string
+	for folder, ok := range g.excludedFolders {
+		if ok {
+			excluded = append(excluded, folder)
+		}
+	}
+	slices.Sort(excluded)
+	return excluded
+}
 
-	g.walkTree("", g.tree.root, func(path string) {
-		if !g.excludedFolders[path] {
-			checkedFolders = append(checkedFolders, path)
+// isExcluded reports if the folder or one of its parents is unchecked
+func (g *GUI) isExcluded(folder string) bool {
+	for excluded := range g.excludedFolders {
+		if search.UnderFolder(folder, excluded) {
+			return true
 		}
-	})
-	return checkedFolders
+	}
+	return false
 }
 
-func (g *GUI) walkTree(currPath string, folder *Folder, callback func(string)) {
+func (g *GUI) parentExcluded(folder string) bool {
+	parent := filepath.Dir(folder)
+	return parent != "." && parent != folder && g.isExcluded(parent)
+}
 
-	if folder == nil {
-		return
-	}
-	if currPath != "" {
-		callback(currPath)
-	}
-	for name, child := range folder.Children {
-		childPath := currPath
-		if childPath == "" {
-			childPath = name
-		} else {
-			childPath = currPath + string(filepath.Separator) + name
+// setExcluded unchecks the folder with its subtree or checks them all again
+func (g *GUI) setExcluded(folder string, excluded bool) {
+	for other := range g.excludedFolders {
+		if search.UnderFolder(other, folder) {
+			delete(g.excludedFolders, other)
 		}
-		g.walkTree(childPath, child, callback)
 	}
+	if excluded {
+		g.excludedFolders[folder] = true
+	}
+	g.folderTree.Refresh()
 }
+
 func (g *GUI) performSearch() {
 	query := g.searchEntry.Text
 	if query == "" {
 		return
 	}
 
-	// sizeFilter := g.sizeFilter.Selected
-
 	// g.previewPanel.previewText.ParseMarkdown("Searching...")
-	fyne.Do(func() {
-		folders := g.getCheckedFolders()
-		stringQuery, err := CreateStringQuery(query)
-		if err != nil {
-			print(err)
-			return
-		}
-		g.searchTerms = GetSearchTerms(stringQuery)
-		results, err := SearchDocuments(stringQuery, folders)
-		if err != nil {
-			print(err)
-			return
-		}
-		// if sizeFilter != "Any Size" {
-		// 	fmt.Printf("Applying size filter: %s\n", sizeFilter)
+	filter, err := g.currentFilter()
+	if err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	req := &search.Request{
+		Query:          query,
+		ExcludeFolders: g.getExcludedFolders(),
+		Highlight:      true,
+		Filter:         filter,
+		NamesOnly:      g.namesOnly.Checked,
+		Similar:        g.similar.Checked,
+		Collapse:       g.collapse.Checked,
+		Blend:          config.LoadGlobalConfig().SimilarBlend,
+		Facets:         true,
+		Size:           search.DefaultPageSize,
+		SortBy:         g.sortBy,
+		Descending:     g.sortDescending,
+	}
+	g.runSearch(req, g.recordSearch)
+}
 
-		// }
+// runSearch shows the first page of results of req
+// the search runs in the background, done is called once its results are shown
+// only the results of the last search are shown
+func (g *GUI) runSearch(req *search.Request, done func()) {
+	g.searchSeq++
+	current := g.searchSeq
+	go func() {
+		res, err := searchEngine.Search(req)
+		fyne.Do(func() {
+			if current != g.searchSeq {
+				return
+			}
+			if err != nil {
+				g.showSearchError(err)
+				return
+			}
+			g.searchRequest = req
+			g.searchTotal = res.Total
+			g.searchMore = res.More(req)
+			// the definitions are highlighted with the words
+			g.searchTerms = append(res.Terms, res.Symbols...)
+			g.searchSymbols = res.Symbols
+			g.showDidYouMean(res.DidYouMean)
+			results := res.Hits
+			g.updateFacets(res.Facets)
+
+			g.updateSearchResults(results)
+			g.resultsTable.ScrollToTop()
+			if done != nil {
+				done()
+			}
+		})
+	}()
+}
 
-		g.updateSearchResults(results)
-	})
+// showDidYouMean offers to search the corrected query
+func (g *GUI) showDidYouMean(corrected string) {
+	if corrected == "" {
+		g.didYouMean.Hide()
+		return
+	}
+	g.didYouMean.SetText("Did you mean: " + corrected + "?")
+	g.didYouMean.OnTapped = func() {
+		g.searchEntry.SetText(corrected)
+		g.performSearch()
+	}
+	g.didYouMean.Show()
 }
-func (g *GUI) loadPreview(filePath string) {
 
-	g.previewPanel.lines = [][]widget.RichTextSegment{}
-	// g.refreshPreviewContent()
-	updateChan := make(chan []widget.RichTextSegment)
+func (g *GUI) loadPreview(filePath string) {
 	re, err := BuildRegexPattern(g.searchTerms)
 	if err != nil {
 		print(err.Error())
 		return
 	}
+	row := -1
+	if len(g.searchSymbols) > 0 {
+		row = DefinitionRow(filePath, g.searchSymbols)
+	}
+	g.showPreview(filePath, re, 0, row)
+}
+
+// showPreview loads the file with the matches of re highlighted
+// and scrolls to the match number match, or to the row when
+// it is not negative
+func (g *GUI) showPreview(filePath string, re *regexp.Regexp, match int, row int) {
+
+	g.previewPanel.lines = [][]widget.RichTextSegment{}
+	// g.refreshPreviewContent()
+	updateChan := make(chan []widget.RichTextSegment)
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
@@ -720,7 +1165,13 @@ func (g *GUI) loadPreview(filePath string) {
 		g.previewPanel.navBar.Show()
 		g.previewPanel.navBar.Refresh()
 		g.previewPanel.previewList.Refresh()
-		g.gotoMatch(0)
+		if row < 0 {
+			g.gotoMatch(match)
+			return
+		}
+		// the first match of the row, it can have none
+		g.gotoMatch(matchOnRow(g.locations, row))
+		g.previewPanel.previewList.ScrollTo(row)
 	})
 }
 
@@ -728,7 +1179,7 @@ func (g *GUI) reindexFolder(path string) {
 	dialog.ShowInformation("Reindexing", fmt.Sprintf("Reindexing folder: %s", path), g.window)
 
 	g.handleFolderOperation(func() (*treeContext, error) {
-		return IndexFolder(path)
+		return ReindexFolder(path)
 	}, "")
 }
 
@@ -758,38 +1209,173 @@ func (g *GUI) createNewIndex() {
 	g.showFolderSelectionDialog(func(folderPath string) {
 
 		confirmMsg := fmt.Sprintf("Create new index for folder:\n\n%s\n\nThis will index all files in the selected folder and its subfolders. Continue?", folderPath)
-
-		dialog.ShowConfirm("Create New Index", confirmMsg, func(confirmed bool) {
+		storeContent := widget.NewCheck("Store content to show highlighted snippets (bigger index)", nil)
+		analyzer := widget.NewSelect(indexer.Analyzers, nil)
+		analyzer.SetSelected(indexer.StandardAnalyzer)
+		detectLanguage := widget.NewCheck("Detect the language of each document", nil)
+		splitCode := widget.NewCheck("Split camelCase and snake_case identifiers of source files", nil)
+		splitCode.SetChecked(true)
+		embeddings := widget.NewCheck("Compute embeddings for the similar meaning search (slower indexing)", nil)
+		content := container.NewVBox(
+			widget.NewLabel(confirmMsg),
+			storeContent,
+			container.NewHBox(widget.NewLabel("Content language"), analyzer),
+			detectLanguage,
+			splitCode,
+			embeddings,
+		)
+
+		dialog.ShowCustomConfirm("Create New Index", "Yes", "No", content, func(confirmed bool) {
 			if confirmed {
-				g.startIndexing(folderPath)
+				opts := indexer.IndexOptions{
+					StoreContent:   storeContent.Checked,
+					Analyzer:       analyzer.Selected,
+					DetectLanguage: detectLanguage.Checked,
+				}
+				if splitCode.Checked {
+					opts.ExtAnalyzers = make(map[string]string)
+					for _, ext := range config.LoadGlobalConfig().CodeExtensions {
+						opts.ExtAnalyzers[ext] = indexer.CodeAnalyzer
+					}
+				}
+				if embeddings.Checked {
+					opts.Embedder = config.LoadGlobalConfig().Embedder
+				}
+				g.startIndexing(folderPath, opts)
 			}
 		}, g.window)
 	})
 }
 
-func (g *GUI) startIndexing(folderPath string) {
+func (g *GUI) startIndexing(folderPath string, opts indexer.IndexOptions) {
 
-	progressDialog := dialog.NewInformation("Indexing", "Indexing folder: "+folderPath+"\n\nPlease wait...", g.window)
+	progressBar := widget.NewProgressBar()
+	statusLabel := widget.NewLabel("Discovering files...")
+	usageLabel := widget.NewLabel("")
+	content := container.NewVBox(
+		widget.NewLabel("Indexing folder: "+folderPath),
+		progressBar,
+		statusLabel,
+		usageLabel,
+	)
+	progressDialog := dialog.NewCustomWithoutButtons("Indexing", content, g.window)
+	pauseButton := widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), nil)
+	pauseButton.OnTapped = func() {
+		if PauseIndexing(folderPath) {
+			pauseButton.SetText("Resume")
+			pauseButton.SetIcon(theme.MediaPlayIcon())
+		} else if ResumeIndexing(folderPath) {
+			pauseButton.SetText("Pause")
+			pauseButton.SetIcon(theme.MediaPauseIcon())
+		}
+	}
+	cancelButton := widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), nil)
+	cancelButton.OnTapped = func() {
+		if CancelIndexing(folderPath) {
+			cancelButton.Disable()
+			pauseButton.Disable()
+			statusLabel.SetText("Cancelling...")
+		}
+	}
+	progressDialog.SetButtons([]fyne.CanvasObject{pauseButton, cancelButton})
+	progressDialog.Resize(fyne.NewSize(DefaultWindowWidth, 0))
 	progressDialog.Show()
 
-	// Simulate indexing process
-	tc, err := IndexFolder(folderPath)
+	go func() {
+		tc, err := IndexFolder(folderPath, opts, func(p coordinator.Progress) {
+			fyne.Do(func() {
+				progressBar.SetValue(p.Fraction())
+				if !cancelButton.Disabled() {
+					statusLabel.SetText(p.String())
+				}
+				usageLabel.SetText(governor.Default().Usage().String())
+			})
+		})
 
-	progressDialog.Hide()
+		fyne.Do(func() {
+			progressDialog.Hide()
 
-	if err != nil {
-		dialog.ShowError(fmt.Errorf("failed to index folder: %v", err), g.window)
-	} else {
-		// g.initTreeContext()
-		dialog.ShowInformation("Success",
-			fmt.Sprintf("Successfully created index for:\n%s\n\nThe folder has been added to your indexed folders.", folderPath),
-			g.window)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to index folder: %v", err), g.window)
+				return
+			}
+			title := "Success"
+			msg := fmt.Sprintf("Successfully created index for:\n%s\n\nThe folder has been added to your indexed folders.", folderPath)
+			if IndexingState(folderPath) == coordinator.JobCancelled {
+				title = "Cancelled"
+				msg = fmt.Sprintf("Indexing of:\n%s\n\nwas cancelled. Files indexed so far are searchable.", folderPath)
+			}
+			dialog.ShowInformation(title, msg, g.window)
 
-		g.tree = tc
-		g.folderTree.Refresh()
-		g.clearSearch()
-		// 	g.previewPanel.previewText.ParseMarkdown("Index created successfully. Enter search terms to begin searching.")
+			g.tree = tc
+			g.folderTree.Refresh()
+			g.clearSearch()
+			// 	g.previewPanel.previewText.ParseMarkdown("Index created successfully. Enter search terms to begin searching.")
+		})
+	}()
+}
+
+// noteUserActivity slows down background indexing while the user works
+func (g *GUI) noteUserActivity() {
+	governor.Default().NoteUserActivity()
+}
+
+func (g *GUI) showResourceUsage() {
+	usage := governor.Default().Usage()
+	limits := usage.Limits
+	bandwidth := "unlimited"
+	if limits.ReadBandwidth > 0 {
+		bandwidth = fmt.Sprintf("%v MB/s", limits.ReadBandwidth/(1024*1024))
 	}
+	msg := fmt.Sprintf("%s\n\nLimits:\nMemory in flight: %v MB\nRead bandwidth: %s\nReaders: %v (%v while active or on battery)\nPriority: nice %v",
+		usage, limits.MaxInFlightMemory/(1024*1024), bandwidth,
+		limits.Readers, limits.BackgroundReaders, limits.Niceness)
+	dialog.ShowInformation("Resource Usage", msg, g.window)
+}
+
+// showResourceLimits edits the limits of the background indexing
+// they apply at once and are kept for the next start
+func (g *GUI) showResourceLimits() {
+	limits, err := config.LoadLimits()
+	if err != nil {
+		fmt.Printf("Error loading the resource limits: %v\n", err)
+	}
+	type field struct {
+		label string
+		value *int64
+	}
+	readers, background, niceness := int64(limits.Readers), int64(limits.BackgroundReaders), int64(limits.Niceness)
+	fields := []field{
+		{"Memory in flight (MB)", &limits.MemoryMB},
+		{"Read bandwidth (MB/s, 0 is unlimited)", &limits.ReadMBPerSec},
+		{"Readers", &readers},
+		{"Readers while active or on battery", &background},
+		{"Priority (nice)", &niceness},
+	}
+	entries := make([]*widget.Entry, len(fields))
+	items := make([]*widget.FormItem, len(fields))
+	for i, f := range fields {
+		entries[i] = widget.NewEntry()
+		entries[i].SetText(strconv.FormatInt(*f.value, 10))
+		entries[i].Validator = func(s string) error {
+			_, err := strconv.ParseInt(s, 10, 64)
+			return err
+		}
+		items[i] = widget.NewFormItem(f.label, entries[i])
+	}
+	dialog.ShowForm("Resource Limits", "Apply", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		for i, f := range fields {
+			*f.value, _ = strconv.ParseInt(entries[i].Text, 10, 64)
+		}
+		limits.Readers, limits.BackgroundReaders, limits.Niceness = int(readers), int(background), int(niceness)
+		governor.Default().SetLimits(governor.LimitsOf(limits))
+		if err := limits.Save(); err != nil {
+			dialog.ShowError(fmt.Errorf("could not save the limits: %v", err), g.window)
+		}
+	}, g.window)
 }
 
 func (g *GUI) Run() {
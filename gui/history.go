@@ -0,0 +1,320 @@
+package gui
+
+import (
+	"GoSeek/config"
+	"GoSeek/internal/alert"
+	"GoSeek/internal/search"
+	"fmt"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxCompletions is the number of past queries offered by the search entry
+const maxCompletions = 10
+
+func (g *GUI) loadHistory() {
+	h, err := config.LoadHistory()
+	if err != nil {
+		fmt.Printf("Error loading the search history: %v\n", err)
+	}
+	g.history = h
+}
+
+func (g *GUI) saveHistory() {
+	if err := g.history.Save(); err != nil {
+		fmt.Printf("Error saving the search history: %v\n", err)
+	}
+}
+
+// lastQuery is the query of the previous session, empty without one
+func (g *GUI) lastQuery() string {
+	if len(g.history.Recent) == 0 {
+		return ""
+	}
+	return g.history.Recent[0].Query
+}
+
+// completeQuery offers the past queries starting like text
+// then the query completed with the words of the indexes
+func (g *GUI) completeQuery(text string) {
+	options := g.history.Complete(text, maxCompletions)
+	words, err := searchEngine.Complete(text, maxCompletions-len(options))
+	if err != nil {
+		fmt.Printf("Error completing the query: %v\n", err)
+	}
+	for _, w := range words {
+		if !slices.Contains(options, w) {
+			options = append(options, w)
+		}
+	}
+	g.searchEntry.SetOptions(options)
+}
+
+// currentSearch is the search of the entry, the filter bar and the folders
+func (g *GUI) currentSearch() config.SavedSearch {
+	s := config.SavedSearch{
+		Query:     g.searchEntry.Text,
+		Exts:      g.extFilter.Text,
+		Paths:     g.pathFilter.Text,
+		NamesOnly: g.namesOnly.Checked,
+		Similar:   g.similar.Checked,
+		Collapse:  g.collapse.Checked,
+	}
+	if g.sizeFilter.SelectedIndex() > 0 {
+		s.Size = g.sizeFilter.Selected
+	}
+	if g.dateFilter.SelectedIndex() > 0 {
+		s.Date = g.dateFilter.Selected
+	}
+	s.Excluded = g.getExcludedFolders()
+	return s
+}
+
+// savedFilter is the filter bar of a saved search
+func savedFilter(s config.SavedSearch) (search.Filter, error) {
+	var filter search.Filter
+	for _, r := range search.SizeRanges {
+		if r.Label == s.Size {
+			r.Apply(&filter)
+		}
+	}
+	for _, f := range dateFilters {
+		if f.label != s.Date || f.since == "" {
+			continue
+		}
+		after, err := search.ParseTime(f.since, time.Now())
+		if err != nil {
+			return filter, err
+		}
+		filter.After = after
+	}
+	filter.Extensions = search.ParseList(s.Exts)
+	filter.PathPrefixes = search.ParseList(s.Paths)
+	return filter, nil
+}
+
+// startAlerts sends the alerts of the saved searches to the desktop
+// and to the log and webhook of the config
+func (g *GUI) startAlerts() {
+	cfg := config.LoadGlobalConfig()
+	alerts.FromConfig(cfg)
+	if cfg.AlertDesktop {
+		alerts.AddNotifier(func(a alert.Alert) error {
+			fyne.Do(func() {
+				g.app.SendNotification(fyne.NewNotification("GoSeek: "+a.Search, a.Path+" matches "+a.Query))
+			})
+			return nil
+		})
+	}
+	g.updateAlerts()
+}
+
+// updateAlerts gives the saved searches marked as alerts to the monitor
+func (g *GUI) updateAlerts() {
+	var standing []alert.Standing
+	for _, s := range g.history.Alerts() {
+		filter, err := savedFilter(s)
+		if err != nil {
+			fmt.Printf("Error in the filter of the alert %q: %v\n", s.Name, err)
+			continue
+		}
+		standing = append(standing, alert.Standing{
+			Name: s.Name,
+			Request: &search.Request{
+				Query:     s.Query,
+				Filter:    filter,
+				NamesOnly: s.NamesOnly,
+			},
+			Excluded: s.Excluded,
+		})
+	}
+	alerts.SetStanding(standing)
+}
+
+// recordSearch adds the search that just ran to the history
+func (g *GUI) recordSearch() {
+	g.history.Record(g.currentSearch())
+	g.saveHistory()
+	g.completeQuery(g.searchEntry.Text)
+}
+
+// runSaved restores the entry, filters and folders of s and runs it
+func (g *GUI) runSaved(s config.SavedSearch) {
+	// the filters run the current search when they change
+	g.searchRequest = nil
+	g.searchEntry.SetText(s.Query)
+	g.extFilter.SetText(s.Exts)
+	g.pathFilter.SetText(s.Paths)
+	g.namesOnly.SetChecked(s.NamesOnly)
+	g.similar.SetChecked(s.Similar)
+	g.collapse.SetChecked(s.Collapse)
+	g.sizeFilter.SetSelectedIndex(0)
+	if s.Size != "" {
+		g.sizeFilter.SetSelected(s.Size)
+	}
+	g.dateFilter.SetSelectedIndex(0)
+	if s.Date != "" {
+		g.dateFilter.SetSelected(s.Date)
+	}
+	clear(g.refinements)
+	g.excludedFolders = make(map[string]bool)
+	for _, folder := range s.Excluded {
+		g.excludedFolders[folder] = true
+	}
+	g.folderTree.Refresh()
+	g.performSearch()
+}
+
+// createSavedPanel lists the saved searches, a click runs one
+func (g *GUI) createSavedPanel() fyne.CanvasObject {
+	g.savedList = widget.NewList(
+		func() int { return len(g.savedSearches) },
+		func() fyne.CanvasObject {
+			pin := widget.NewButtonWithIcon("", theme.RadioButtonIcon(), nil)
+			pin.Importance = widget.LowImportance
+			watch := widget.NewButtonWithIcon("", theme.VisibilityOffIcon(), nil)
+			watch.Importance = widget.LowImportance
+			remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			remove.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, pin, container.NewHBox(watch, remove), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			s := g.savedSearches[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(s.Name)
+			pin := row.Objects[1].(*widget.Button)
+			if s.Pinned {
+				pin.SetIcon(theme.RadioButtonCheckedIcon())
+			} else {
+				pin.SetIcon(theme.RadioButtonIcon())
+			}
+			pin.OnTapped = func() {
+				g.history.Pin(s.Name, !s.Pinned)
+				g.refreshSaved()
+			}
+			buttons := row.Objects[2].(*fyne.Container)
+			// watched searches alert on the new matching documents
+			watch := buttons.Objects[0].(*widget.Button)
+			if s.Alert {
+				watch.SetIcon(theme.VisibilityIcon())
+			} else {
+				watch.SetIcon(theme.VisibilityOffIcon())
+			}
+			watch.OnTapped = func() {
+				g.history.SetAlert(s.Name, !s.Alert)
+				g.refreshSaved()
+			}
+			buttons.Objects[1].(*widget.Button).OnTapped = func() {
+				dialog.ShowConfirm("Delete Saved Search", fmt.Sprintf("Delete %q?", s.Name), func(ok bool) {
+					if ok {
+						g.history.RemoveSearch(s.Name)
+						g.refreshSaved()
+					}
+				}, g.window)
+			}
+		},
+	)
+	g.savedList.OnSelected = func(id widget.ListItemID) {
+		g.savedList.UnselectAll()
+		if id < len(g.savedSearches) {
+			g.runSaved(g.savedSearches[id])
+		}
+	}
+	g.savedSearches = g.history.SavedSearches()
+
+	label := widget.NewLabel("Saved Searches")
+	label.TextStyle.Bold = true
+	save := widget.NewButtonWithIcon("", theme.ContentAddIcon(), g.showSaveSearch)
+	return container.NewBorder(container.NewBorder(nil, nil, nil, save, label), nil, nil, nil, g.savedList)
+}
+
+// refreshSaved saves the history and lists the saved searches again
+func (g *GUI) refreshSaved() {
+	g.saveHistory()
+	g.savedSearches = g.history.SavedSearches()
+	g.savedList.Refresh()
+	g.updateAlerts()
+}
+
+// showSaveSearch asks a name for the current search and saves it
+func (g *GUI) showSaveSearch() {
+	current := g.currentSearch()
+	if current.Query == "" {
+		dialog.ShowInformation("Save Search", "Type a query to save first.", g.window)
+		return
+	}
+	name := widget.NewEntry()
+	name.SetText(current.Query)
+	pinned := widget.NewCheck("Pin to the top", nil)
+	alerted := widget.NewCheck("Alert when new or updated files match", nil)
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", name),
+		widget.NewFormItem("", pinned),
+		widget.NewFormItem("", alerted),
+	}
+	dialog.ShowForm("Save Search", "Save", "Cancel", items, func(ok bool) {
+		if !ok || name.Text == "" {
+			return
+		}
+		g.history.SaveSearch(name.Text, current)
+		g.history.Pin(name.Text, pinned.Checked)
+		g.history.SetAlert(name.Text, alerted.Checked)
+		g.refreshSaved()
+	}, g.window)
+}
+
+// showHistory lists the past searches, a click runs one again
+func (g *GUI) showHistory() {
+	w := g.app.NewWindow("Search History")
+	recent := g.history.Recent
+	list := widget.NewList(
+		func() int { return len(recent) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(recent[id].Query)
+			row.Objects[1].(*widget.Label).SetText(formatModTime(recent[id].LastRun))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		g.runSaved(recent[id])
+		w.Close()
+	}
+	clearButton := widget.NewButtonWithIcon("Clear History", theme.DeleteIcon(), func() {
+		g.clearHistory(func() {
+			recent = nil
+			list.Refresh()
+		})
+	})
+	pause := widget.NewCheck("Pause history, new searches are not recorded", func(paused bool) {
+		g.history.Paused = paused
+		g.saveHistory()
+	})
+	pause.SetChecked(g.history.Paused)
+	w.SetContent(container.NewBorder(nil, container.NewHBox(pause, clearButton), nil, nil, list))
+	w.Resize(fyne.NewSize(600, 400))
+	w.Show()
+}
+
+// clearHistory forgets the past searches once the user confirms
+func (g *GUI) clearHistory(done func()) {
+	dialog.ShowConfirm("Clear History", "Forget every past search? Saved searches are kept.", func(ok bool) {
+		if !ok {
+			return
+		}
+		g.history.ClearHistory()
+		g.saveHistory()
+		g.completeQuery("")
+		if done != nil {
+			done()
+		}
+	}, g.window)
+}
@@ -2,20 +2,22 @@ package gui
 
 import (
 	"GoSeek/config"
+	"GoSeek/internal/alert"
+	"GoSeek/internal/code"
 	"GoSeek/internal/coordinator"
 	"GoSeek/internal/indexer"
-	"GoSeek/internal/models"
+	"GoSeek/internal/search"
 	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/widget"
 	"github.com/blevesearch/bleve/v2"
-	"github.com/blevesearch/bleve/v2/search/query"
 )
 
 type Folder struct {
@@ -28,7 +30,31 @@ var root = &Folder{
 	Children: make(map[string]*Folder),
 }
 
-var FolderIndex = make(map[string]*coordinator.Coordinator)
+// FolderIndex is written by the indexing goroutines and read by the UI,
+// go through folderCoordinator and setFolderCoordinator
+var (
+	FolderIndex   = make(map[string]*coordinator.Coordinator)
+	folderIndexMu sync.RWMutex
+)
+
+func folderCoordinator(name string) (*coordinator.Coordinator, bool) {
+	folderIndexMu.RLock()
+	defer folderIndexMu.RUnlock()
+	c, ok := FolderIndex[name]
+	return c, ok && c != nil
+}
+
+func setFolderCoordinator(name string, c *coordinator.Coordinator) {
+	folderIndexMu.Lock()
+	FolderIndex[name] = c
+	folderIndexMu.Unlock()
+}
+
+// searchEngine searches the indexes of FolderIndex
+var searchEngine = search.NewEngine()
+
+// alerts runs the saved searches marked as alerts on the live updates
+var alerts = alert.NewMonitor(searchEngine)
 
 // CreateTree creates prefix tree (trie) from all paths in index
 func CreateTreeFromIndex(root *Folder, prePath string, res *bleve.SearchResult, c *coordinator.Coordinator) *Folder {
@@ -45,7 +71,7 @@ func CreateTreeFromIndex(root *Folder, prePath string, res *bleve.SearchResult,
 			continue
 		}
 		if _, ok := vis[dir]; !ok { // prevent duplicates
-			c.UpdateChan <- prePath + string(filepath.Separator) + dir
+			c.WatchDir(prePath + string(filepath.Separator) + dir)
 			vis[dir] = true
 			insertToTree(root, dir)
 		}
@@ -73,7 +99,9 @@ func GetPaths(index *indexer.BleveIndexer) *bleve.SearchResult {
 }
 
 // Get All prevIndexes on the fly when app reopen
-func GetIndexes() *Folder {
+// onTreeChange is called on the UI thread once the folders of a continued scan
+// are added to the tree
+func GetIndexes(onTreeChange func()) *Folder {
 	data, err := config.ReadFromFile()
 	if err != nil {
 		fmt.Printf("Error reading config: %v\n", err)
@@ -87,15 +115,31 @@ func GetIndexes() *Folder {
 		}
 
 		c := coordinator.NewCoordinatorPrevIndex(trimmedPath)
-		FolderIndex[filepath.Base(path)] = c
+		setFolderCoordinator(filepath.Base(path), c)
 		if c == nil || c.Indexer == nil {
 			continue // Skip if coordinator creation failed
 		}
+		searchEngine.Add(filepath.Base(trimmedPath), c.Indexer)
+		c.SetOnIndexed(alerts.Check)
 		paths := GetPaths(c.Indexer)
 		if paths != nil && len(paths.Hits) > 0 {
 			// println(len(paths.Hits))
 			CreateTreeFromIndex(root, filepath.Dir(trimmedPath), paths, c)
 		}
+
+		// The app stopped in the middle of the initial scan
+		// continue it in the background and add the new folders when done
+		c.SetOnComplete(func() {
+			paths := GetPaths(c.Indexer)
+			// the tree is read by the folder tree widget
+			fyne.Do(func() {
+				CreateTreeFromIndex(root, filepath.Dir(trimmedPath), paths, c)
+				onTreeChange()
+			})
+		})
+		if c.ContinueInterruptedScan() {
+			fmt.Printf("Continuing interrupted indexing of %s\n", trimmedPath)
+		}
 	}
 	return root
 }
@@ -125,135 +169,21 @@ func insertToTree(root *Folder, path string) {
 	}
 }
 
-// Search in all indexes found with specific query
-func SearchDocuments(queryString *query.QueryStringQuery, folders []string) ([]models.Document, error) {
-	var results []models.Document
-	indexGroups := groupFolders(folders)
-	for coord, dirs := range indexGroups {
-		dirQuery := CreateDirQuery(dirs)
-		query := bleve.NewConjunctionQuery(queryString, dirQuery)
-		searchRequest := bleve.NewSearchRequest(query)
-		searchRequest.Size = 1000 // Limit results count for now (rare to be more than that) handle later
-		searchRequest.Fields = []string{"path", "score", "size", "mod_time", "extension"}
-		res, err := coord.Indexer.Search(searchRequest)
-		if err != nil {
-			return nil, err
-		}
-		results = append(results, res...)
-	}
-	// for _, res := range results {
-	// 	println(res.Dir)
-	// }
-	return results, nil
-}
-
-// Group Folders according to coord (Index)
-func groupFolders(folders []string) map[*coordinator.Coordinator][]string {
-	groups := make(map[*coordinator.Coordinator][]string)
-	for _, folder := range folders {
-		for parentFolder, coord := range FolderIndex {
-			// println(parentFolder, " ", folder, " ", coord)
-			if strings.HasPrefix(folder, parentFolder) {
-				groups[coord] = append(groups[coord], folder)
-			}
-		}
-	}
-	return groups
-}
-
-func CreateDirQuery(dirs []string) *query.DisjunctionQuery {
-	queries := make([]query.Query, 0, len(dirs))
-	for _, dir := range dirs {
-		q := bleve.NewTermQuery(dir)
-		q.SetField("dir")
-		queries = append(queries, q)
-	}
-	dirQuery := bleve.NewDisjunctionQuery(queries...)
-	return dirQuery
-}
-
-func CreateStringQuery(queryString string) (*query.QueryStringQuery, error) {
-	StringQuery := bleve.NewQueryStringQuery(queryString)
-	// println(keywordQuery.Query)
-	err := StringQuery.Validate()
-	if err != nil {
-		return nil, err
-	}
-	return StringQuery, nil
-}
-
-func GetSearchTerms(queryString *query.QueryStringQuery) []string {
-	parseQuery, _ := queryString.Parse()
-	searchTerms := walkQuery(parseQuery)
-	return searchTerms
-}
-func walkQuery(q query.Query) []string {
-	switch t := q.(type) {
-	case *query.TermQuery:
-		// println("T-->", t.Term)
-		return []string{t.Term}
-	case *query.MatchQuery:
-		// println("M-->", t.Match)
-		return []string{t.Match}
-	case *query.BooleanQuery:
-		var out []string
-		if t.Must != nil {
-			if mustSlice, ok := (t.Must).(*query.ConjunctionQuery); ok {
-				for _, must := range mustSlice.Conjuncts {
-					out = append(out, walkQuery(must)...)
-				}
-			}
-		}
-		if t.Should != nil {
-			if shouldSlice, ok := (t.Should).(*query.DisjunctionQuery); ok {
-				for _, should := range shouldSlice.Disjuncts {
-					// println("S")
-					out = append(out, walkQuery(should)...)
-				}
-			}
-			if t.MustNot != nil {
-				if mustNotSlice, ok := (t.MustNot).(*query.DisjunctionQuery); ok {
-					for _, mustNot := range mustNotSlice.Disjuncts {
-						out = append(out, walkQuery(mustNot)...)
-					}
-				}
-			}
-		}
-		return out
-	case *query.ConjunctionQuery:
-		var out []string
-		for _, sub := range t.Conjuncts {
-			out = append(out, walkQuery(sub)...)
-		}
-		return out
-	case *query.DisjunctionQuery:
-		var out []string
-		for _, sub := range t.Disjuncts {
-			out = append(out, walkQuery(sub)...)
-		}
-		return out
-	case *query.RegexpQuery:
-		// println("R--->", t.Regexp)
-		return []string{"/" + t.Regexp + "/"}
-	}
-	return nil
-}
-
 // Create New index
-func IndexFolder(path string) (*treeContext, error) {
+// onProgress receives the progress of the scan until it is done
+func IndexFolder(path string, opts indexer.IndexOptions, onProgress func(coordinator.Progress)) (*treeContext, error) {
 	config.SaveToFile(path)
-	// use some defined extensions for now
-	extensions := map[string]bool{
-		".txt": true,
-		".log": true,
-		".md":  true,
-		".go":  true,
-		".py":  true,
+	extensions := config.LoadGlobalConfig().Extensions()
+	coord := coordinator.NewCoordinator(path, extensions, opts)
+	if coord == nil {
+		return nil, fmt.Errorf("could not create index for %s", path)
 	}
-	coord := coordinator.NewCoordinator(path, extensions)
-	FolderIndex[filepath.Base(path)] = coord
-	done := make(chan struct{})
+	setFolderCoordinator(filepath.Base(path), coord)
+	searchEngine.Add(filepath.Base(path), coord.Indexer)
+	coord.SetOnIndexed(alerts.Check)
+	done := make(chan struct{}, 1)
 
+	coord.SetOnProgress(onProgress)
 	coord.SetOnComplete(func() {
 		select {
 		case done <- struct{}{}:
@@ -264,17 +194,72 @@ func IndexFolder(path string) (*treeContext, error) {
 	// Start the initial scan
 	coord.IntialScan(path)
 
-	<-done // what until Done
-	// TODO :
-	// fix : The function may end before last batch indexes
+	<-done // wait until every file is committed
 	paths := GetPaths(coord.Indexer)
-	CreateTreeFromIndex(root, path, paths, coord)
+	CreateTreeFromIndex(root, filepath.Dir(path), paths, coord)
 	return &treeContext{
 		root:      root,
 		treeCache: make(map[string]*Folder),
 	}, nil
 }
 
+// ReindexFolder scans an indexed folder again
+// it runs before the background work of the other indexes
+func ReindexFolder(name string) (*treeContext, error) {
+	coord, ok := folderCoordinator(name)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an indexed folder", name)
+	}
+	done := make(chan struct{}, 1)
+	coord.SetOnComplete(func() {
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+	})
+	coord.Reindex()
+
+	<-done
+	path, _ := coord.Indexer.Index.GetInternal([]byte("__base_path__"))
+	CreateTreeFromIndex(root, string(path), GetPaths(coord.Indexer), coord)
+	return &treeContext{
+		root:      root,
+		treeCache: make(map[string]*Folder),
+	}, nil
+}
+
+// CancelIndexing stops the scan running on the index of path
+func CancelIndexing(path string) bool {
+	if coord, ok := folderCoordinator(filepath.Base(path)); ok {
+		return coord.CancelScan()
+	}
+	return false
+}
+
+// IndexingState returns the state of the last scan on the index of path
+func IndexingState(path string) coordinator.JobState {
+	if coord, ok := folderCoordinator(filepath.Base(path)); ok {
+		return coord.ScanState()
+	}
+	return coordinator.JobIdle
+}
+
+// PauseIndexing holds the scan running on the index of path
+func PauseIndexing(path string) bool {
+	if coord, ok := folderCoordinator(filepath.Base(path)); ok {
+		return coord.PauseScan()
+	}
+	return false
+}
+
+// ResumeIndexing continues the paused scan on the index of path
+func ResumeIndexing(path string) bool {
+	if coord, ok := folderCoordinator(filepath.Base(path)); ok {
+		return coord.ResumeScan()
+	}
+	return false
+}
+
 func RemoveFolder(path string) (*treeContext, error) {
 	// Mock removal function
 	fmt.Printf("Removing folder from index: %s\n", path)
@@ -360,6 +345,69 @@ func GetDocumentPreview(path string, re *regexp.Regexp, updateChan chan []widget
 	return locations, nil
 }
 
+// matchOnRow is the number of the first match on the row
+// or of the last one before it
+func matchOnRow(locations map[int]location, row int) int {
+	match := 0
+	for i := 0; i < len(locations); i++ {
+		if locations[i].rowId > row {
+			break
+		}
+		match = i
+		if locations[i].rowId == row {
+			break
+		}
+	}
+	return match
+}
+
+// DefinitionRow finds the first definition of one of the symbols
+// in the source file and returns its row in the preview, -1 if none
+// symbols between slashes are regular expressions of the whole name
+func DefinitionRow(path string, symbols []string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return -1
+	}
+	parsed := code.Parse(path, string(data))
+	if parsed == nil {
+		return -1
+	}
+	var patterns []*regexp.Regexp
+	for _, s := range symbols {
+		if isRegexInput(s) {
+			if re, err := regexp.Compile(`(?i)^(?:` + s[1:len(s)-1] + `)$`); err == nil {
+				patterns = append(patterns, re)
+			}
+		} else {
+			patterns = append(patterns, regexp.MustCompile(`(?i)^`+regexp.QuoteMeta(s)+`$`))
+		}
+	}
+	for _, sym := range parsed.Symbols {
+		for _, re := range patterns {
+			if re.MatchString(sym.Name) {
+				return previewRow(string(data), sym.Line)
+			}
+		}
+	}
+	return -1
+}
+
+// previewRow turns a line number in the row showing it,
+// the preview leaves the empty lines out
+func previewRow(content string, line int) int {
+	row := 0
+	for i, l := range strings.Split(content, "\n") {
+		if i >= line-1 {
+			break
+		}
+		if strings.TrimSuffix(l, "\r") != "" {
+			row++
+		}
+	}
+	return row
+}
+
 func isRegexInput(input string) bool {
 	return len(input) >= 2 && input[0] == '/' && input[len(input)-1] == '/'
 }
@@ -0,0 +1,62 @@
+package gui
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// date layouts by language or language_REGION of the user's locale
+var dateLayouts = map[string]string{
+	"en_US": "01/02/2006 3:04 PM",
+	"en_CA": "2006-01-02 3:04 PM",
+	"en":    "02/01/2006 15:04",
+	"de":    "02.01.2006 15:04",
+	"ru":    "02.01.2006 15:04",
+	"pl":    "02.01.2006 15:04",
+	"fr":    "02/01/2006 15:04",
+	"es":    "02/01/2006 15:04",
+	"it":    "02/01/2006 15:04",
+	"pt":    "02/01/2006 15:04",
+	"nl":    "02-01-2006 15:04",
+	"ja":    "2006/01/02 15:04",
+	"zh":    "2006/01/02 15:04",
+	"ko":    "2006. 01. 02. 15:04",
+	"ar":    "02/01/2006 15:04",
+}
+
+// isoLayout is used when the locale is unknown
+const isoLayout = "2006-01-02 15:04"
+
+var dateLayout = localeDateLayout()
+
+// localeDateLayout picks the layout of the locale in the environment
+// like "en_US.UTF-8" from LC_ALL, LC_TIME or LANG
+func localeDateLayout() string {
+	locale := ""
+	for _, env := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if locale = os.Getenv(env); locale != "" {
+			break
+		}
+	}
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.ReplaceAll(locale, "-", "_")
+	if layout, ok := dateLayouts[locale]; ok {
+		return layout
+	}
+	lang, _, _ := strings.Cut(locale, "_")
+	if layout, ok := dateLayouts[lang]; ok {
+		return layout
+	}
+	return isoLayout
+}
+
+// formatModTime shows a modification time in the local zone and locale
+func formatModTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(dateLayout)
+}
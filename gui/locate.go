@@ -0,0 +1,83 @@
+package gui
+
+import (
+	"GoSeek/internal/locate"
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxLocateResults is the number of names listed while typing
+const maxLocateResults = 200
+
+var locateModes = []string{"Auto", "Prefix", "Substring", "Fuzzy"}
+
+// showLocate opens a window finding files by name as you type
+// selecting a file shows it in the preview of the main window
+func (g *GUI) showLocate() {
+	w := g.app.NewWindow("Find File by Name")
+	w.Resize(fyne.NewSize(DefaultWindowWidth, DefaultWindowHeight))
+
+	var matches []locate.Match
+	seq := 0 // only the results of the last keystroke are shown
+	status := widget.NewLabel("")
+	list := widget.NewList(
+		func() int { return len(matches) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			box := item.(*fyne.Container)
+			name := box.Objects[0].(*widget.Label)
+			dir := box.Objects[1].(*widget.Label)
+			name.TextStyle.Bold = true
+			name.SetText(filepath.Base(matches[id].Path))
+			dir.SetText(truncateText(filepath.Dir(matches[id].Path), 80))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		if id < len(matches) {
+			g.loadPreview(matches[id].Path)
+		}
+	}
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("File name...")
+	mode := widget.NewSelect(locateModes, nil)
+	mode.SetSelectedIndex(0)
+
+	run := func() {
+		g.noteUserActivity()
+		seq++
+		current := seq
+		name := entry.Text
+		m, _ := locate.ParseMode(mode.Selected)
+		go func() {
+			found, err := searchEngine.Locate(name, m, maxLocateResults)
+			fyne.Do(func() {
+				if current != seq {
+					return
+				}
+				if err != nil {
+					status.SetText(err.Error())
+					return
+				}
+				matches = found
+				list.UnselectAll()
+				list.Refresh()
+				list.ScrollToTop()
+				status.SetText(fmt.Sprintf("%d files", len(found)))
+			})
+		}()
+	}
+	entry.OnChanged = func(string) { run() }
+	mode.OnChanged = func(string) { run() }
+
+	top := container.NewBorder(nil, nil, nil, mode, entry)
+	w.SetContent(container.NewBorder(top, status, nil, nil, list))
+	w.Canvas().Focus(entry)
+	w.Show()
+}
@@ -0,0 +1,38 @@
+package gui
+
+import (
+	"GoSeek/internal/querylang"
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showSearchError tells what is wrong with the query
+// syntax errors show the query with a caret under the problem
+func (g *GUI) showSearchError(err error) {
+	var syntaxErr *querylang.Error
+	if !errors.As(err, &syntaxErr) {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	caret := widget.NewLabel(syntaxErr.Caret())
+	caret.TextStyle.Monospace = true
+	msg := widget.NewLabel(syntaxErr.Error())
+	help := widget.NewButton("Query Syntax", func() {
+		g.showQuerySyntax()
+	})
+	content := container.NewVBox(caret, msg, container.NewHBox(help))
+	dialog.ShowCustom("Invalid Query", "OK", content, g.window)
+}
+
+// showQuerySyntax lists what can be typed in the search box
+func (g *GUI) showQuerySyntax() {
+	text := widget.NewLabel(querylang.Syntax)
+	text.TextStyle.Monospace = true
+	scroll := container.NewScroll(text)
+	scroll.SetMinSize(fyne.NewSize(640, 420))
+	dialog.ShowCustom("Query Syntax", "Close", scroll, g.window)
+}
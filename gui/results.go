@@ -0,0 +1,100 @@
+package gui
+
+import (
+	"GoSeek/internal/models"
+	"GoSeek/internal/search"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// resultCell is a cell of the results table
+// a right click opens the actions of its row
+type resultCell struct {
+	widget.BaseWidget
+	content *fyne.Container // a label, or the snippet with its matches highlighted
+	row     int
+	onMenu  func(row int, pos fyne.Position)
+}
+
+func newResultCell(onMenu func(row int, pos fyne.Position)) *resultCell {
+	c := &resultCell{
+		content: container.NewStack(widget.NewLabel(""), widget.NewRichText()),
+		onMenu:  onMenu,
+	}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *resultCell) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(c.content)
+}
+
+func (c *resultCell) TappedSecondary(e *fyne.PointEvent) {
+	// the header row sorts, it has no actions
+	if c.row > 0 {
+		c.onMenu(c.row, e.AbsolutePosition)
+	}
+}
+
+// showResultMenu shows the actions on the result of the table row
+func (g *GUI) showResultMenu(row int, pos fyne.Position) {
+	if row-1 >= len(g.searchResults) {
+		return
+	}
+	result := g.searchResults[row-1]
+	menu := fyne.NewMenu("Result Actions",
+		fyne.NewMenuItem("Preview", func() {
+			g.loadPreview(result.Path)
+		}),
+		fyne.NewMenuItem("More Like This", func() {
+			g.showMoreLikeThis(result.Path)
+		}),
+		fyne.NewMenuItem("Properties", func() {
+			g.showProperties(result)
+		}),
+	)
+	// the copies hidden behind the result
+	if len(result.Copies) > 0 {
+		var items []*fyne.MenuItem
+		for _, path := range result.Copies {
+			items = append(items, fyne.NewMenuItem(path, func() {
+				g.loadPreview(path)
+			}))
+		}
+		copies := fyne.NewMenuItem("Copies", nil)
+		copies.ChildMenu = fyne.NewMenu("", items...)
+		menu.Items = append(menu.Items, copies)
+	}
+	widget.ShowPopUpMenuAtPosition(menu, g.window.Canvas(), pos)
+}
+
+// showProperties shows the metadata of a result
+func (g *GUI) showProperties(result models.Document) {
+	lines := append([]string{"path: " + result.Path}, search.Metadata(result, true)...)
+	dialog.ShowInformation(filepath.Base(result.Path), strings.Join(lines, "\n"), g.window)
+}
+
+// showMoreLikeThis lists the documents like the result at path
+// in the checked folders and with the filter of the bar
+func (g *GUI) showMoreLikeThis(path string) {
+	filter, err := g.currentFilter()
+	if err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	g.runSearch(&search.Request{
+		Like:           path,
+		ExcludeFolders: g.getExcludedFolders(),
+		Highlight:      true,
+		Filter:         filter,
+		Facets:         true,
+		Size:           search.DefaultPageSize,
+		SortBy:         g.sortBy,
+		Descending:     g.sortDescending,
+	}, nil)
+}
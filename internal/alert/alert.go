@@ -0,0 +1,154 @@
+package alert
+
+import (
+	"GoSeek/config"
+	"GoSeek/internal/search"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Standing is a saved search run on every new or updated document
+type Standing struct {
+	Name     string
+	Request  *search.Request // its Query, Filter and NamesOnly are used
+	Excluded []string        // folders left out with their sub folders
+}
+
+// Alert is a new or updated document matching a standing query
+type Alert struct {
+	Search string    `json:"search"` // name of the saved search
+	Query  string    `json:"query"`
+	Path   string    `json:"path"` // of the matching file
+	Score  float64   `json:"score"`
+	Time   time.Time `json:"time"`
+}
+
+// Notifier raises an alert, through the desktop, a log or a webhook
+type Notifier func(a Alert) error
+
+// Monitor runs the standing queries on the documents the coordinators
+// committed from live updates and raises an alert for every match
+type Monitor struct {
+	engine   *search.Engine
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	standing  []Standing
+	notifiers []Notifier
+	last      map[string]time.Time // last alert by search and path
+
+	sendMu sync.Mutex // the notifiers are called one at a time
+}
+
+func NewMonitor(engine *search.Engine) *Monitor {
+	return &Monitor{
+		engine:   engine,
+		cooldown: config.LoadGlobalConfig().AlertCooldown,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// SetStanding replaces the standing queries
+func (m *Monitor) SetStanding(standing []Standing) {
+	m.mu.Lock()
+	m.standing = standing
+	m.mu.Unlock()
+}
+
+// AddNotifier sends the next alerts to n too
+func (m *Monitor) AddNotifier(n Notifier) {
+	if n == nil {
+		return
+	}
+	m.mu.Lock()
+	m.notifiers = append(m.notifiers, n)
+	m.mu.Unlock()
+}
+
+// Check runs the standing queries on the committed documents ids
+// it is the OnIndexed callback of the coordinators
+func (m *Monitor) Check(ids []string) {
+	m.mu.Lock()
+	standing, notifiers := m.standing, m.notifiers
+	m.mu.Unlock()
+	if len(standing) == 0 || len(notifiers) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, s := range standing {
+		scoped := inScope(ids, s.Excluded)
+		if len(scoped) == 0 {
+			continue
+		}
+		req := *s.Request
+		req.IDs = scoped
+		req.From, req.Size = 0, len(scoped)
+		// the nearest documents of a few new ones are not similar ones
+		req.Similar, req.Like = false, ""
+		req.Highlight, req.Facets = false, false
+		res, err := m.engine.Search(&req)
+		if err != nil {
+			fmt.Printf("Error running the alert %q: %v\n", s.Name, err)
+			continue
+		}
+		for _, hit := range res.Hits {
+			if !m.due(s.Name, hit.Path, now) {
+				continue
+			}
+			m.send(notifiers, Alert{
+				Search: s.Name,
+				Query:  s.Request.Query,
+				Path:   hit.Path,
+				Score:  hit.Score,
+				Time:   now,
+			})
+		}
+	}
+}
+
+// due reports if the file raises a new alert for the search
+// a file written again and again alerts once per cooldown
+func (m *Monitor) due(name, path string, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, t := range m.last {
+		if now.Sub(t) >= m.cooldown {
+			delete(m.last, key)
+		}
+	}
+	key := name + "\x00" + path
+	if _, ok := m.last[key]; ok {
+		return false
+	}
+	m.last[key] = now
+	return true
+}
+
+func (m *Monitor) send(notifiers []Notifier, a Alert) {
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+	for _, n := range notifiers {
+		if err := n(a); err != nil {
+			fmt.Printf("Error sending the alert %q: %v\n", a.Search, err)
+		}
+	}
+}
+
+// inScope leaves out the documents of the excluded folders
+func inScope(ids []string, excluded []string) []string {
+	if len(excluded) == 0 {
+		return ids
+	}
+	var scoped []string
+	for _, id := range ids {
+		if !slices.ContainsFunc(excluded, func(folder string) bool {
+			return search.UnderFolder(filepath.Dir(id), folder)
+		}) {
+			scoped = append(scoped, id)
+		}
+	}
+	return scoped
+}
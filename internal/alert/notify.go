@@ -0,0 +1,95 @@
+package alert
+
+import (
+	"GoSeek/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const webhookTimeout = 5 * time.Second
+
+// Log writes a line for every alert
+func Log(w io.Writer) Notifier {
+	return func(a Alert) error {
+		_, err := fmt.Fprintf(w, "%s alert %q: %s matches %s\n",
+			a.Time.Format(time.RFC3339), a.Search, a.Path, a.Query)
+		return err
+	}
+}
+
+// LogFile appends the alerts to the file at path, "-" is the standard output
+func LogFile(path string) (Notifier, error) {
+	if path == "-" {
+		return Log(os.Stdout), nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return Log(file), nil
+}
+
+// Webhook posts every alert as JSON to rawURL
+// the alerts name the files of the user, only local endpoints get them
+func Webhook(rawURL string) (Notifier, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("webhook %s is not an http URL", rawURL)
+	}
+	if !local(u.Hostname()) {
+		return nil, fmt.Errorf("webhook %s is not a local endpoint", rawURL)
+	}
+	client := &http.Client{Timeout: webhookTimeout}
+	return func(a Alert) error {
+		body, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Post(u.String(), "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s answered %s", rawURL, resp.Status)
+		}
+		return nil
+	}, nil
+}
+
+func local(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// FromConfig adds the log and webhook notifiers of the global config
+// the desktop notifications are up to the GUI
+func (m *Monitor) FromConfig(cfg *config.GlobalConfig) {
+	if cfg.AlertLog != "" {
+		n, err := LogFile(cfg.AlertLog)
+		if err != nil {
+			fmt.Printf("Error opening the alert log: %v\n", err)
+		}
+		m.AddNotifier(n)
+	}
+	if cfg.AlertWebhook != "" {
+		n, err := Webhook(cfg.AlertWebhook)
+		if err != nil {
+			fmt.Printf("Error setting the alert webhook: %v\n", err)
+		}
+		m.AddNotifier(n)
+	}
+}
@@ -0,0 +1,77 @@
+// Package code finds the definitions, comments and string literals
+// of source files so they can be indexed in their own fields.
+// Go files are parsed with go/parser, the other languages are
+// split by a lightweight tokenizer knowing their comment and
+// string syntax and the keywords starting a definition.
+package code
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Symbol is a definition in a source file
+type Symbol struct {
+	Name string
+	Kind string // func, method, type, var, const, class...
+	Line int    // 1 based
+}
+
+// File is what was found in a source file
+type File struct {
+	Symbols  []Symbol
+	Comments []string
+	Strings  []string
+}
+
+// Supported reports if files with that extension are parsed
+func Supported(ext string) bool {
+	ext = strings.ToLower(ext)
+	if ext == ".go" {
+		return true
+	}
+	_, ok := languages[ext]
+	return ok
+}
+
+// Parse finds the definitions, comments and strings of src
+// the language comes from the extension of path, nil if unknown
+// a Go file with syntax errors is tokenized like the other languages
+func Parse(path string, src string) *File {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		if f, err := parseGo(path, src); err == nil {
+			return f
+		}
+		return tokenize(src, goTokens)
+	}
+	lang, ok := languages[ext]
+	if !ok {
+		return nil
+	}
+	return tokenize(src, lang)
+}
+
+// SymbolNames are the names of the definitions, once each
+func (f *File) SymbolNames() []string {
+	seen := make(map[string]bool, len(f.Symbols))
+	names := make([]string, 0, len(f.Symbols))
+	for _, s := range f.Symbols {
+		if !seen[s.Name] {
+			seen[s.Name] = true
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// Definition is the first definition of name, case insensitive
+// ok is false when the file does not define it
+func (f *File) Definition(name string) (Symbol, bool) {
+	for _, s := range f.Symbols {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
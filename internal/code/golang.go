@@ -0,0 +1,85 @@
+package code
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// parseGo finds the package level and local definitions of a Go file
+func parseGo(path string, src string) (*File, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{}
+	add := func(ident *ast.Ident, kind string) {
+		if ident == nil || ident.Name == "_" {
+			return
+		}
+		f.Symbols = append(f.Symbols, Symbol{Name: ident.Name, Kind: kind, Line: fset.Position(ident.Pos()).Line})
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.ImportSpec:
+			return false // the paths are no strings of the program
+		case *ast.FuncDecl:
+			if t.Recv != nil {
+				add(t.Name, "method")
+			} else {
+				add(t.Name, "func")
+			}
+		case *ast.TypeSpec:
+			add(t.Name, "type")
+		case *ast.GenDecl:
+			if t.Tok != token.CONST && t.Tok != token.VAR {
+				break
+			}
+			for _, spec := range t.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					add(name, t.Tok.String())
+				}
+			}
+		case *ast.AssignStmt:
+			if t.Tok == token.DEFINE {
+				for _, lhs := range t.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						add(ident, "var")
+					}
+				}
+			}
+		case *ast.StructType:
+			addFields(t.Fields, "field", add)
+		case *ast.InterfaceType:
+			addFields(t.Methods, "method", add)
+		case *ast.BasicLit:
+			if t.Kind == token.STRING {
+				if s, err := strconv.Unquote(t.Value); err == nil && strings.TrimSpace(s) != "" {
+					f.Strings = append(f.Strings, s)
+				}
+			}
+		}
+		return true
+	})
+	for _, group := range file.Comments {
+		if text := strings.TrimSpace(group.Text()); text != "" {
+			f.Comments = append(f.Comments, text)
+		}
+	}
+	return f, nil
+}
+
+// addFields adds the named fields of a struct or the methods of an interface
+func addFields(list *ast.FieldList, kind string, add func(*ast.Ident, string)) {
+	if list == nil {
+		return
+	}
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			add(name, kind)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package code
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// language is what the tokenizer knows of a language
+type language struct {
+	lineComments  []string
+	blockComments [][2]string
+	quotes        []string // longest first, """ before "
+	rawQuotes     []string // quotes without escapes
+	// keywords followed by the name they define, with its kind
+	definitions map[string]string
+	// skipped between a keyword and the name, like mut in let mut x
+	modifiers []string
+	// typed functions are found by their shape, int main(...) {
+	typedFuncs bool
+}
+
+var cLike = language{
+	lineComments:  []string{"//"},
+	blockComments: [][2]string{{"/*", "*/"}},
+	quotes:        []string{`"`, `'`},
+}
+
+func with(base language, definitions map[string]string, change func(*language)) *language {
+	l := base
+	l.definitions = definitions
+	if change != nil {
+		change(&l)
+	}
+	return &l
+}
+
+var (
+	python = with(language{
+		lineComments: []string{"#"},
+		quotes:       []string{`"""`, `'''`, `"`, `'`},
+	}, map[string]string{"def": "func", "class": "class"}, nil)
+
+	javascript = with(cLike, map[string]string{
+		"function": "func", "class": "class", "const": "const", "let": "var", "var": "var",
+		"interface": "type", "type": "type", "enum": "type",
+	}, func(l *language) {
+		l.quotes = []string{`"`, `'`, "`"}
+		l.modifiers = []string{"async", "*"}
+	})
+
+	java = with(cLike, map[string]string{
+		"class": "class", "interface": "type", "enum": "type", "record": "class",
+	}, func(l *language) { l.typedFuncs = true })
+
+	c = with(cLike, map[string]string{
+		"struct": "type", "enum": "type", "union": "type", "define": "const",
+	}, func(l *language) { l.typedFuncs = true })
+
+	cpp = with(cLike, map[string]string{
+		"struct": "type", "enum": "type", "union": "type", "define": "const",
+		"class": "class", "namespace": "namespace",
+	}, func(l *language) { l.typedFuncs = true })
+
+	csharp = with(cLike, map[string]string{
+		"class": "class", "struct": "type", "interface": "type", "enum": "type",
+		"record": "class", "namespace": "namespace",
+	}, func(l *language) { l.typedFuncs = true })
+
+	rust = with(cLike, map[string]string{
+		"fn": "func", "struct": "type", "enum": "type", "trait": "type", "type": "type",
+		"mod": "namespace", "const": "const", "static": "var", "let": "var",
+	}, func(l *language) {
+		l.quotes = []string{`"`} // ' starts lifetimes too
+		l.modifiers = []string{"mut"}
+	})
+
+	ruby = with(language{
+		lineComments:  []string{"#"},
+		blockComments: [][2]string{{"=begin", "=end"}},
+		quotes:        []string{`"`, `'`},
+	}, map[string]string{"def": "func", "class": "class", "module": "namespace"}, nil)
+
+	php = with(cLike, map[string]string{
+		"function": "func", "class": "class", "interface": "type", "trait": "type", "const": "const",
+	}, func(l *language) { l.lineComments = []string{"//", "#"} })
+
+	kotlin = with(cLike, map[string]string{
+		"fun": "func", "class": "class", "interface": "type", "object": "class",
+		"val": "const", "var": "var",
+	}, func(l *language) {
+		l.quotes = []string{`"""`, `"`, `'`}
+		l.rawQuotes = []string{`"""`}
+	})
+
+	swift = with(cLike, map[string]string{
+		"func": "func", "class": "class", "struct": "type", "enum": "type",
+		"protocol": "type", "let": "const", "var": "var",
+	}, func(l *language) { l.quotes = []string{`"""`, `"`} })
+
+	// Go files go/parser can't read
+	goTokens = with(cLike, map[string]string{
+		"func": "func", "type": "type", "var": "var", "const": "const",
+	}, func(l *language) {
+		l.quotes = []string{`"`, `'`, "`"}
+		l.rawQuotes = []string{"`"}
+	})
+)
+
+// languages by extension, .go is parsed by go/parser
+var languages = map[string]*language{
+	".py": python, ".pyw": python,
+	".js": javascript, ".jsx": javascript, ".mjs": javascript, ".ts": javascript, ".tsx": javascript,
+	".java": java,
+	".c":    c, ".h": c,
+	".cpp": cpp, ".cc": cpp, ".cxx": cpp, ".hpp": cpp,
+	".cs":  csharp,
+	".rs":  rust,
+	".rb":  ruby,
+	".php": php,
+	".kt":  kotlin, ".kts": kotlin,
+	".swift": swift,
+}
+
+// notFuncs can be followed by ( without being a function name or its type
+var notFuncs = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"new": true, "else": true, "case": true, "throw": true, "sizeof": true, "typeof": true,
+	"await": true, "delete": true, "using": true, "lock": true, "foreach": true, "do": true,
+}
+
+// tokenize scans src for comments, strings and definitions
+func tokenize(src string, lang *language) *File {
+	t := &tokenizer{src: src, lang: lang, line: 1, f: &File{}}
+	t.run()
+	return t.f
+}
+
+type tokenizer struct {
+	src  string
+	pos  int
+	line int
+	lang *language
+	f    *File
+	// the definition keyword waiting for its name
+	keyword string
+	// the word before, for typed functions
+	prevWord string
+	// line of the last comment, to join the following one
+	lastCommentLine int
+}
+
+func (t *tokenizer) run() {
+	for t.pos < len(t.src) {
+		rest := t.src[t.pos:]
+		if t.comment(rest) || t.quoted(rest) {
+			t.keyword, t.prevWord = "", ""
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case r == '\n':
+			t.line++
+			t.pos += size
+		case unicode.IsSpace(r):
+			t.pos += size
+		case isIdentStart(r):
+			t.word()
+		default:
+			if !(t.keyword != "" && t.isModifier(string(r))) {
+				t.keyword = ""
+			}
+			// int *f(...) or Foo<T> bar(...)
+			if r != '*' && r != '&' && r != '>' && r != ']' {
+				t.prevWord = ""
+			}
+			t.pos += size
+		}
+	}
+}
+
+// comment adds the comment starting rest, if any
+func (t *tokenizer) comment(rest string) bool {
+	for _, open := range t.lang.lineComments {
+		if strings.HasPrefix(rest, open) {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			t.addComment(rest[len(open):end])
+			t.pos += end
+			return true
+		}
+	}
+	for _, block := range t.lang.blockComments {
+		if strings.HasPrefix(rest, block[0]) {
+			end := strings.Index(rest[len(block[0]):], block[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(block[0]) + len(block[1])
+			}
+			text := rest[:end]
+			t.addComment(strings.TrimSuffix(strings.TrimPrefix(text, block[0]), block[1]))
+			t.line += strings.Count(text, "\n")
+			t.pos += end
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tokenizer) addComment(text string) {
+	text = strings.TrimSpace(strings.TrimLeft(text, "/*!# \t"))
+	if text == "" {
+		return
+	}
+	// a comment spanning lines is one, like go/ast comment groups
+	n := len(t.f.Comments)
+	if n > 0 && t.lastCommentLine == t.line-1 && !strings.Contains(text, "\n") {
+		t.f.Comments[n-1] += "\n" + text
+	} else {
+		t.f.Comments = append(t.f.Comments, text)
+	}
+	t.lastCommentLine = t.line + strings.Count(text, "\n")
+}
+
+// quoted adds the string literal starting rest, if any
+func (t *tokenizer) quoted(rest string) bool {
+	for _, q := range t.lang.quotes {
+		if !strings.HasPrefix(rest, q) {
+			continue
+		}
+		raw := len(q) == 3 || slices.Contains(t.lang.rawQuotes, q)
+		text := rest[len(q):]
+		end, closed := closing(text, q, raw)
+		text = text[:end]
+		if strings.TrimSpace(text) != "" {
+			t.f.Strings = append(t.f.Strings, text)
+		}
+		t.line += strings.Count(text, "\n")
+		t.pos += len(q) + end
+		if closed {
+			t.pos += len(q)
+		}
+		return true
+	}
+	return false
+}
+
+// closing finds the end quote, escaped ones are skipped
+// a string with one line quotes ends with the line
+func closing(s string, q string, raw bool) (int, bool) {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case !raw && s[i] == '\\':
+			i++
+		case !raw && len(q) == 1 && s[i] == '\n':
+			return i, false
+		case strings.HasPrefix(s[i:], q):
+			return i, true
+		}
+	}
+	return len(s), false
+}
+
+func (t *tokenizer) word() {
+	start := t.pos
+	for t.pos < len(t.src) {
+		r, size := utf8.DecodeRuneInString(t.src[t.pos:])
+		if !isIdentStart(r) && !unicode.IsDigit(r) {
+			break
+		}
+		t.pos += size
+	}
+	w := t.src[start:t.pos]
+	switch {
+	case t.keyword != "" && t.isModifier(w):
+		return
+	case t.keyword != "":
+		if _, isKeyword := t.lang.definitions[w]; !isKeyword {
+			t.addSymbol(w, t.lang.definitions[t.keyword])
+			t.keyword = ""
+			t.prevWord = w
+			return
+		}
+	case t.lang.typedFuncs && t.prevWord != "" && !notFuncs[t.prevWord] && !notFuncs[w] && t.isFuncDefinition():
+		t.addSymbol(w, "func")
+	}
+	if _, ok := t.lang.definitions[w]; ok {
+		t.keyword = w
+	} else {
+		t.keyword = ""
+	}
+	t.prevWord = w
+}
+
+// isFuncDefinition reports if the word just read is followed
+// by a parameter list and a body, the shape of a definition
+func (t *tokenizer) isFuncDefinition() bool {
+	rest := strings.TrimLeft(t.src[t.pos:], " \t")
+	if !strings.HasPrefix(rest, "(") {
+		return false
+	}
+	depth := 0
+	for i, r := range rest {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				after := strings.TrimLeftFunc(rest[i+1:], unicode.IsSpace)
+				for _, word := range []string{"const", "override", "noexcept", "throws"} {
+					if strings.HasPrefix(after, word) {
+						return true
+					}
+				}
+				return strings.HasPrefix(after, "{")
+			}
+		case ';', '{', '}':
+			return false
+		}
+	}
+	return false
+}
+
+func (t *tokenizer) addSymbol(name, kind string) {
+	t.f.Symbols = append(t.f.Symbols, Symbol{Name: name, Kind: kind, Line: t.line})
+}
+
+func (t *tokenizer) isModifier(w string) bool {
+	return slices.Contains(t.lang.modifiers, w)
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '$'
+}
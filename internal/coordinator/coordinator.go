@@ -3,11 +3,14 @@ package coordinator
 import (
 	"GoSeek/config"
 	"GoSeek/internal/fileprocessor"
+	"GoSeek/internal/governor"
 	"GoSeek/internal/indexer"
 	"GoSeek/internal/models"
+	"GoSeek/internal/scheduler"
 	"GoSeek/internal/watcher"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,31 +19,67 @@ import (
 	"time"
 )
 
-type WorkItem struct {
-	Type     string // "create", "delete", "update"
-	FilePath string
-	// Data     interface{}
-}
+const (
+	idleFlushInterval = 1 * time.Second
+	progressInterval  = 500 * time.Millisecond
+	namesSaveInterval = 30 * time.Second
+)
+
+var errScanCancelled = errors.New("scan cancelled")
 
+// Coordinator runs the work of one index
+// files are read by the shared scheduler pool and folders are watched
+// by the shared watcher, only the batching of documents is owned by the coordinator
 type Coordinator struct {
 	fileprocessor *fileprocessor.FileProcessor
-	watcher       *watcher.FileWatcher
 	Indexer       *indexer.BleveIndexer
 	// Cfg           *config.IndexConfig
 
+	root  string // folder of the index
+	name  string // owner name of the tasks in the scheduler
+	sched *scheduler.Scheduler
+	mux   *watcher.Mux
+
 	// Persistent channels
-	workChan chan WorkItem
-	fileChan chan string
-	docChan  chan *models.Document
+	docChan chan readDoc
 
 	// Worker control
-	ctx         context.Context
-	cancel      context.CancelFunc
-	wg          sync.WaitGroup
-	UpdateChan  chan string
-	onComplete  func()
-	pendingWork int32
-	mu          sync.RWMutex
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	onComplete func()
+	onProgress func(Progress)
+	onIndexed  func(ids []string)
+	mu         sync.RWMutex
+
+	// Job accounting
+	counters   jobCounters
+	job        atomic.Pointer[Job]
+	checkpoint *indexer.ScanCheckpoint
+	cpMu       sync.Mutex
+
+	// Files of a paused job waiting to be resubmitted
+	parked   []parkedFile
+	parkedMu sync.Mutex
+
+	// generation of the file names seen by the current scan
+	namesGen atomic.Uint32
+
+	// documents of live updates waiting to be committed
+	live   map[string]bool
+	liveMu sync.Mutex
+}
+
+// readDoc is a document read within the memory of the governor
+// cost is given back as acquired, the limits may have changed since
+type readDoc struct {
+	doc  *models.Document
+	cost int64
+}
+
+type parkedFile struct {
+	path     string
+	priority scheduler.Priority
 }
 
 var gCfg *config.GlobalConfig = config.LoadGlobalConfig()
@@ -48,147 +87,366 @@ var gCfg *config.GlobalConfig = config.LoadGlobalConfig()
 // TODO:
 // Use another dynamic way to intiallize coordinators
 // of prevIndexes or new ones
-func NewCoordinator(folderPath string, extensions map[string]bool) *Coordinator {
-	ctx, cancel := context.WithCancel(context.Background())
+func NewCoordinator(folderPath string, extensions map[string]bool, opts indexer.IndexOptions) *Coordinator {
 	// indexPath := "index/" + filepath.Base(folderPath)
-	indexer, err := indexer.NewBleveIndexer(folderPath, extensions)
+	indexer, err := indexer.NewBleveIndexer(folderPath, extensions, opts)
 	if err != nil {
-		cancel()
 		println(err)
 		return nil
 	}
-	coord := &Coordinator{
-		fileprocessor: fileprocessor.NewFileProcessor(filepath.Dir(folderPath), extensions, gCfg.ChunkSize, gCfg.NumWorkers),
-		Indexer:       indexer,
-
-		// channels
-		workChan: make(chan WorkItem, gCfg.ChannelBufferSize*2),
-		fileChan: make(chan string, gCfg.ChannelBufferSize*4),
-		docChan:  make(chan *models.Document, gCfg.ChannelBufferSize),
-
-		ctx:        ctx,
-		cancel:     cancel,
-		UpdateChan: make(chan string, 4),
-	}
-
-	coord.watcher = watcher.NewFileWatcher(
-		func(path string) { coord.workChan <- WorkItem{Type: "delete", FilePath: path} },
-		func(path string) { coord.workChan <- WorkItem{Type: "update", FilePath: path} },
-		func(path string) { coord.workChan <- WorkItem{Type: "create", FilePath: path} },
-	)
-
-	// Start persistent workers
-	coord.startWorkers()
-	coord.watcher.StartWatching()
-
-	return coord
+	return newCoordinator(folderPath, indexer, extensions)
 }
 func NewCoordinatorPrevIndex(path string) *Coordinator {
-	ctx, cancel := context.WithCancel(context.Background())
 	indexPath := "index/" + filepath.Base(path)
-	indexer := indexer.OpenBleve(indexPath)
-	data, err := indexer.Index.GetInternal([]byte("__extensions__"))
+	index := indexer.OpenBleve(indexPath)
+	if index == nil {
+		return nil
+	}
+	data, err := index.Index.GetInternal([]byte("__extensions__"))
 	if err != nil {
-		cancel()
 		return nil // For Now
 	}
 	var extensions map[string]bool
 	json.Unmarshal(data, &extensions)
+	// the rebuilt index is filled by ContinueInterruptedScan
+	if index.Outdated() {
+		fmt.Printf("Rebuilding index of %s with schema version %d\n", path, indexer.SchemaVersion)
+		index, err = indexer.Migrate(path, extensions, index)
+		if err != nil {
+			fmt.Printf("Error rebuilding index of %s: %v\n", path, err)
+			return nil
+		}
+	}
+	return newCoordinator(path, index, extensions)
+}
+
+func newCoordinator(folderPath string, index *indexer.BleveIndexer, extensions map[string]bool) *Coordinator {
+	ctx, cancel := context.WithCancel(context.Background())
 	coord := &Coordinator{
-		fileprocessor: fileprocessor.NewFileProcessor(filepath.Dir(path), extensions, gCfg.ChunkSize, gCfg.NumWorkers),
-		Indexer:       indexer,
+		fileprocessor: fileprocessor.NewFileProcessor(filepath.Dir(folderPath), extensions, gCfg.ChunkSize, gCfg.NumWorkers),
+		Indexer:       index,
+
+		root:  filepath.Clean(folderPath),
+		name:  filepath.Base(folderPath),
+		sched: scheduler.Default(),
 
 		// channels
-		workChan: make(chan WorkItem, gCfg.ChannelBufferSize*2),
-		fileChan: make(chan string, gCfg.ChannelBufferSize*4),
-		docChan:  make(chan *models.Document, gCfg.ChannelBufferSize),
+		docChan: make(chan readDoc, gCfg.ChannelBufferSize),
 
-		ctx:        ctx,
-		cancel:     cancel,
-		UpdateChan: make(chan string, 2),
+		ctx:    ctx,
+		cancel: cancel,
+
+		live: make(map[string]bool),
+	}
+	if e := index.Embedder(); e != nil {
+		coord.fileprocessor.SetEmbedder(e)
 	}
 
-	coord.watcher = watcher.NewFileWatcher(
-		func(path string) { coord.workChan <- WorkItem{Type: "delete", FilePath: path} },
-		func(path string) { coord.workChan <- WorkItem{Type: "update", FilePath: path} },
-		func(path string) { coord.workChan <- WorkItem{Type: "create", FilePath: path} },
-	)
+	mux, err := watcher.DefaultMux()
+	if err != nil {
+		fmt.Printf("Error starting the watcher: %v\n", err)
+	} else {
+		coord.mux = mux
+		mux.Register(coord.root, watcher.Handlers{
+			OnDelete: coord.onDelete,
+			OnWrite:  coord.onChange,
+			OnCreate: coord.onCreate,
+		})
+	}
 
-	// Start persistent workers
-	coord.startWorkers()
-	coord.watcher.StartWatching()
+	// Single document indexer owning the batches of the index
+	coord.wg.Add(1)
+	go coord.documentIndexer()
 
 	return coord
 }
 
-func (c *Coordinator) startWorkers() {
-	// Single work dispatcher
-	c.wg.Add(1)
-	go c.workDispatcher()
+// onDelete removes the document of a deleted file
+// TODO :
+// Trade off between:
+// --> Delete in batchs in case of multiple deletes come
+// less time but timer will be created and call flush every t seconds (in case of limit of flush unreached)
+// --> Delete in single files as delete event is not frequent in our main program purpose
+func (c *Coordinator) onDelete(path string) {
+	relPath := c.fileprocessor.RelPath(path)
+	c.Indexer.DeleteSingleDocument(relPath)
+	c.Indexer.Names.Remove(relPath)
+}
 
-	for i := 0; i < gCfg.NumWorkers/2; i++ {
-		c.wg.Add(2)
-		// file processor pool
-		go c.fileProcess()
-		// Document indexer
-		go c.documentIndexer()
+// onCreate adds the name of a new file or folder
+// before indexing it like a written one
+func (c *Coordinator) onCreate(path string) {
+	c.addName(path)
+	c.onChange(path)
+}
+
+// addName records a path in the file name index
+func (c *Coordinator) addName(path string) {
+	c.Indexer.Names.Add(c.fileprocessor.RelPath(path))
+}
+
+func (c *Coordinator) saveNames() {
+	if err := c.Indexer.SaveNames(); err != nil {
+		fmt.Printf("Error saving file names: %v\n", err)
 	}
-	c.wg.Add(1)
-	go c.AddDir()
 }
 
-func (c *Coordinator) workDispatcher() {
-	defer c.wg.Done()
+// onChange reindexes a created or written file as a live update
+func (c *Coordinator) onChange(path string) {
+	c.enqueue(path, scheduler.Live)
+}
 
-	for {
-		select {
-		case <-c.ctx.Done():
+// WatchDir adds the folder to the watched ones
+func (c *Coordinator) WatchDir(path string) {
+	if c.mux == nil {
+		return
+	}
+	if err := c.mux.Add(path); err != nil {
+		fmt.Printf("Error watching %v: %v\n", path, err)
+	}
+}
+
+// process runs on the scheduler pool for every queued path
+func (c *Coordinator) process(filePath string, priority scheduler.Priority) {
+	if c.ctx.Err() != nil {
+		return
+	}
+	// live updates are not part of the scan, a paused or cancelled
+	// scan must not leave the index stale
+	if job := c.job.Load(); job != nil && priority != scheduler.Live {
+		switch job.gate() {
+		case JobPaused:
+			// Do not hold a pool worker while paused
+			c.park(filePath, priority)
+			return
+		case JobCancelled:
+			atomic.AddInt64(&c.counters.skipped, 1)
+			c.finish(1)
 			return
-		case work := <-c.workChan:
-			switch work.Type {
-			case "delete":
-				// Delete single file
-				// TODO :
-				// Trade off between:
-				// --> Delete in batchs in case of multiple deletes come
-				// less time but timer will be created and call flush every t seconds (in case of limit of flush unreached)
-				// --> Delete in single files as delete event is not frequent in our main program purpose
-				c.Indexer.DeleteSingleDocument(work.FilePath)
-			case "create", "update":
-				c.fileChan <- work.FilePath
-			}
 		}
 	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		fmt.Printf("Error in stat file %v\n", err)
+		atomic.AddInt64(&c.counters.failed, 1)
+		c.finish(1)
+		return
+	}
+	// It is a folder --> Walk and give me the files
+	if info.IsDir() {
+		go c.walk(filePath, priority)
+		return
+	}
+	// the watcher sees every file, not only the indexed extensions
+	if !c.fileprocessor.Allowed(filePath) {
+		c.finish(1)
+		return
+	}
+	// It is file then read its content
+	// send on docChan to start indexing
+	// println("BEFORE READING", info.Name())
+	if priority == scheduler.Live {
+		c.markLive(filePath)
+	}
+	if err := c.readFile(filePath, info); err != nil {
+		c.takeLive(c.fileprocessor.RelPath(filePath))
+		fmt.Println(err)
+		atomic.AddInt64(&c.counters.failed, 1)
+		c.finish(1)
+		return
+	}
+	atomic.AddInt64(&c.counters.read, 1)
 }
 
-func (c *Coordinator) fileProcess() {
-	defer c.wg.Done()
-	for {
-		select {
-		case <-c.ctx.Done():
-			return
-		case filePath := <-c.fileChan:
+// walk discovers the files of a folder, it runs outside the pool
+// so a blocked walker never holds a worker
+// the pending count of the folder is released when the walk ends
+func (c *Coordinator) walk(folder string, priority scheduler.Priority) {
+	atomic.AddInt32(&c.counters.walking, 1)
+	err := c.fileprocessor.Walk(folder, func(path string) error {
+		return c.discover(path, priority)
+	}, func(path string) {
+		c.WatchDir(path)
+		c.addName(path)
+	}, c.addName)
+	if err != nil && !errors.Is(err, errScanCancelled) && !errors.Is(err, context.Canceled) {
+		fmt.Printf("Error walking the directory: %v\n", err)
+	}
+	atomic.AddInt32(&c.counters.walking, -1)
+	c.finish(1)
+}
 
-			file, err := os.Open(filePath)
-			if err != nil {
-				print(err)
-				continue
-			}
-			info, _ := file.Stat()
-			file.Close()
-			// It is a folder --> Walk and give me the files
-			if info.IsDir() {
-				c.fileprocessor.Walk(filePath, c.fileChan, c.UpdateChan)
-				continue
-			}
-			// It is file then read its content
-			// send on docChan to start indexing
-			// println("BEFORE READING", info.Name())
-			c.fileprocessor.Read(filePath, info, c.docChan, &c.pendingWork)
+// park keeps the file of a paused job until it is resumed or cancelled
+func (c *Coordinator) park(path string, priority scheduler.Priority) {
+	c.parkedMu.Lock()
+	c.parked = append(c.parked, parkedFile{path: path, priority: priority})
+	c.parkedMu.Unlock()
+}
+
+// unpark sends the parked files back to the scheduler
+// from a goroutine, the bulk queue blocks once full and
+// the callers are the buttons of the UI
+func (c *Coordinator) unpark() {
+	c.parkedMu.Lock()
+	parked := c.parked
+	c.parked = nil
+	c.parkedMu.Unlock()
+	if len(parked) == 0 {
+		return
+	}
+	go func() {
+		for _, p := range parked {
+			c.submit(p.path, p.priority)
+		}
+	}()
+}
+
+// dropParked forgets the parked files of a job replaced by a new scan
+// the new scan finds them again
+func (c *Coordinator) dropParked() {
+	c.parkedMu.Lock()
+	c.parked = nil
+	c.parkedMu.Unlock()
+}
+
+// readFile reads the file within the limits of the governor
+// the memory of the document is given back once it is committed
+func (c *Coordinator) readFile(filePath string, info os.FileInfo) error {
+	gov := governor.Default()
+	if err := gov.AcquireReader(c.ctx); err != nil {
+		return err
+	}
+	defer gov.ReleaseReader()
+
+	cost := gov.MemoryCost(info.Size())
+	if err := gov.AcquireMemory(c.ctx, cost); err != nil {
+		return err
+	}
+	doc, err := c.fileprocessor.Read(filePath, info)
+	if err != nil {
+		gov.ReleaseMemory(cost)
+		return err
+	}
+	select {
+	case c.docChan <- readDoc{doc: doc, cost: cost}:
+	case <-c.ctx.Done():
+		gov.ReleaseMemory(cost)
+		return c.ctx.Err()
+	}
+	return nil
+}
+
+// markLive remembers the file was created or written while watched
+func (c *Coordinator) markLive(path string) {
+	c.liveMu.Lock()
+	c.live[c.fileprocessor.RelPath(path)] = true
+	c.liveMu.Unlock()
+}
+
+// takeLive reports if the document comes from a live update
+// and forgets it
+func (c *Coordinator) takeLive(id string) bool {
+	c.liveMu.Lock()
+	defer c.liveMu.Unlock()
+	live := c.live[id]
+	delete(c.live, id)
+	return live
+}
+
+// discover is called by the walker for every file found
+func (c *Coordinator) discover(path string, priority scheduler.Priority) error {
+	if priority == scheduler.Live {
+		// a folder created while watched, not part of the scan
+		c.enqueue(path, priority)
+		return nil
+	}
+	if err := c.wait(); err != nil {
+		return err
+	}
+	atomic.AddInt64(&c.counters.discovered, 1)
+	if job := c.job.Load(); job != nil && job.resumed && c.unchanged(path) {
+		// Committed before the interruption
+		atomic.AddInt64(&c.counters.skipped, 1)
+		return nil
+	}
+	c.enqueue(path, priority)
+	return nil
+}
+
+// unchanged reports if the file is indexed as it is on disk
+// a file written since the interruption is read again
+func (c *Coordinator) unchanged(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && c.Indexer.Unchanged(c.fileprocessor.RelPath(path), info)
+}
+
+// enqueue counts the path as pending before sending it to the scheduler
+// so the job can not be seen as finished while it is in flight
+func (c *Coordinator) enqueue(path string, priority scheduler.Priority) {
+	atomic.AddInt64(&c.counters.pending, 1)
+	c.submit(path, priority)
+}
+
+// submit queues an already pending path
+func (c *Coordinator) submit(path string, priority scheduler.Priority) {
+	ok := c.sched.Submit(c.ctx, scheduler.Task{
+		Owner:    c.name,
+		Priority: priority,
+		Run:      func() { c.process(path, priority) },
+	})
+	if !ok {
+		atomic.AddInt64(&c.counters.skipped, 1)
+		c.finish(1)
+	}
+}
+
+// finish marks n pending paths as done
+// and completes the scan when nothing is left
+func (c *Coordinator) finish(n int64) {
+	if atomic.AddInt64(&c.counters.pending, -n) != 0 {
+		return
+	}
+	if job := c.job.Load(); job != nil && job.complete() {
+		state := indexer.ScanDone
+		if job.State() == JobCancelled {
+			state = indexer.ScanCancelled
+		} else {
+			// the names the finished scan did not see are gone
+			c.Indexer.Names.DropOlder(c.namesGen.Load())
 		}
+		c.saveNames()
+		c.saveCheckpoint(state)
+		c.triggerProgress()
+		c.triggerComplete()
 	}
 }
 
+// saveCheckpoint records the state of the scan in the index
+// an empty state keeps the current one
+func (c *Coordinator) saveCheckpoint(state string) {
+	c.cpMu.Lock()
+	defer c.cpMu.Unlock()
+	cp := c.checkpoint
+	if cp == nil {
+		return
+	}
+	if state != "" {
+		cp.State = state
+	}
+	if err := c.Indexer.SaveCheckpoint(cp); err != nil {
+		fmt.Printf("Error saving scan checkpoint: %v\n", err)
+	}
+}
+
+// wait blocks while the current job is paused
+func (c *Coordinator) wait() error {
+	job := c.job.Load()
+	if job == nil {
+		return nil
+	}
+	return job.wait(c.ctx)
+}
+
 func (c *Coordinator) SetOnComplete(callback func()) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -206,84 +464,259 @@ func (c *Coordinator) triggerComplete() {
 	}
 }
 
+// SetOnIndexed calls back with the documents of every committed batch
+// created or written while watched, the scans do not report theirs
+func (c *Coordinator) SetOnIndexed(callback func(ids []string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onIndexed = callback
+}
+
+func (c *Coordinator) triggerIndexed(ids []string) {
+	c.mu.RLock()
+	callback := c.onIndexed
+	c.mu.RUnlock()
+
+	if callback != nil {
+		callback(ids)
+	}
+}
+
+func (c *Coordinator) SetOnProgress(callback func(Progress)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onProgress = callback
+}
+
+// Progress returns the current snapshot of the indexing job
+func (c *Coordinator) Progress() Progress {
+	job := c.job.Load()
+	if job == nil {
+		return Progress{State: JobIdle, Done: true}
+	}
+	p := c.counters.snapshot(job.Elapsed())
+	p.State = job.State()
+	p.Done = !job.Active()
+	return p
+}
+
+func (c *Coordinator) triggerProgress() {
+	c.mu.RLock()
+	callback := c.onProgress
+	c.mu.RUnlock()
+
+	if callback != nil {
+		callback(c.Progress())
+	}
+}
+
+// reportProgress sends progress events until the scan is done
+func (c *Coordinator) reportProgress() {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if job := c.job.Load(); job == nil || !job.Active() {
+				return
+			}
+			c.triggerProgress()
+		}
+	}
+}
+
 func (c *Coordinator) documentIndexer() {
 	defer c.wg.Done()
 	batch := c.Indexer.NewBatch()
 	var batchSize int32
 	var batchCount int32
-	flushBatch := c.Indexer.FlushBatch
+	var batchMemory int64 // memory held in the governor by the batch
+	var fresh []string    // documents of live updates in the batch
+	gov := governor.Default()
 
-	// Add a ticker to periodically check for completion
-	// Think of better way to avoid polling
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+	flush := func() {
+		if batchCount == 0 {
+			return
+		}
+		count, size := int64(batchCount), int64(batchSize)
+		defer func() {
+			gov.ReleaseMemory(batchMemory)
+			batchMemory = 0
+		}()
+		if err := c.Indexer.FlushBatch(batch, &batchSize, &batchCount); err != nil {
+			atomic.AddInt64(&c.counters.failed, count)
+		} else {
+			atomic.AddInt64(&c.counters.committed, count)
+			atomic.AddInt64(&c.counters.bytes, size)
+			c.cpMu.Lock()
+			if c.checkpoint != nil {
+				c.checkpoint.Committed += count
+			}
+			c.cpMu.Unlock()
+			c.saveCheckpoint("")
+			if len(fresh) > 0 {
+				// the callback searches the committed documents
+				go c.triggerIndexed(fresh)
+			}
+		}
+		fresh = nil
+		batch = c.Indexer.NewBatch()
+		c.finish(count)
+	}
+
+	// Flush small batches once the readers go quiet
+	// so the last documents of a job are committed without waiting for the limit
+	idle := time.NewTimer(idleFlushInterval)
+	defer idle.Stop()
+	// the watcher changes the file names without going through the batches
+	saveNames := time.NewTicker(namesSaveInterval)
+	defer saveNames.Stop()
 
 	for {
 		select {
 		case <-c.ctx.Done():
 			// Process remaining batch
-			if batchCount > 0 {
-				flushBatch(batch, &batchSize, &batchCount)
-			}
-			atomic.StoreInt32(&c.pendingWork, 0)
-			c.triggerComplete()
+			flush()
 			return
-		case doc := <-c.docChan:
+		case read := <-c.docChan:
+			doc := read.doc
 			// println("INDEXER: ", doc.Path)
 			if err := c.Indexer.IndexDocument(batch, doc); err != nil {
-				atomic.AddInt32(&c.pendingWork, -1)
 				fmt.Printf("Error adding document to batch: %v\n", err)
+				gov.ReleaseMemory(read.cost)
+				atomic.AddInt64(&c.counters.failed, 1)
+				c.finish(1)
 				continue
 			}
-			// println("NORMAL: ", atomic.LoadInt32(&c.pendingWork))
-
-			atomic.AddInt32(&batchSize, int32(len(doc.Content)))
-			atomic.AddInt32(&batchCount, 1)
+			atomic.AddInt64(&c.counters.batched, 1)
+			if c.takeLive(doc.Path) {
+				fresh = append(fresh, doc.Path)
+			}
+			batchSize += int32(len(doc.Content))
+			batchCount++
+			batchMemory += read.cost
 
 			// Check if batch should be flushed
-			if atomic.LoadInt32(&batchSize) >= atomic.LoadInt32(&gCfg.IndexBatchMemoryLimit) {
-				// println("Before Batch: ", atomic.LoadInt32(&c.pendingWork))
-				atomic.AddInt32(&c.pendingWork, -batchCount)
-				flushBatch(batch, &batchSize, &batchCount)
-				// println("After Batch: ", atomic.LoadInt32(&c.pendingWork))
-				batch = c.Indexer.NewBatch()
-			}
-		case <-ticker.C:
-			// Periodically check for completion and flush small batches
-			if batchCount > 0 {
-				// println("Before Batch: ", atomic.LoadInt32(&c.pendingWork))
-				atomic.AddInt32(&c.pendingWork, -batchCount)
-				flushBatch(batch, &batchSize, &batchCount)
-				// println("After Batch: ", atomic.LoadInt32(&c.pendingWork))
-				batch = c.Indexer.NewBatch()
-			}
-			// ticker.Reset(10 * time.Second)
-			// println(atomic.LoadInt32(&c.pendingWork))
-			// Check if all work is done
-			if atomic.LoadInt32(&c.pendingWork) == 0 {
-				c.triggerComplete()
+			// flush early when the memory of all indexes is close to the limit
+			if batchSize >= atomic.LoadInt32(&gCfg.IndexBatchMemoryLimit) || gov.UnderPressure() {
+				flush()
 			}
+			idle.Reset(idleFlushInterval)
+		case <-idle.C:
+			flush()
+		case <-saveNames.C:
+			c.saveNames()
 		}
 	}
 }
 
+// IntialScan indexes all files under filePath as a new job
+// progress is reported to the OnProgress callback
+// and OnComplete is called once every file is committed, skipped or failed
 func (c *Coordinator) IntialScan(filePath string) {
-	atomic.StoreInt32(&c.pendingWork, 0)
-	c.workChan <- WorkItem{Type: "create", FilePath: filePath}
+	c.startScan(&indexer.ScanCheckpoint{
+		Root:      filePath,
+		StartedAt: time.Now(),
+	}, false, scheduler.Bulk)
+}
+
+// Reindex scans the folder of the index again before any background work
+func (c *Coordinator) Reindex() {
+	c.startScan(&indexer.ScanCheckpoint{
+		Root:      c.root,
+		StartedAt: time.Now(),
+	}, false, scheduler.Interactive)
+}
+
+// ContinueInterruptedScan restarts the initial scan if the app stopped in the middle of it
+// documents committed before are skipped
+// it reports false if there is nothing to continue
+func (c *Coordinator) ContinueInterruptedScan() bool {
+	cp, err := c.Indexer.LoadCheckpoint()
+	if err != nil {
+		fmt.Printf("Error loading scan checkpoint: %v\n", err)
+		return false
+	}
+	if !cp.Interrupted() {
+		return false
+	}
+	c.startScan(cp, true, scheduler.Bulk)
+	return true
+}
+
+func (c *Coordinator) startScan(cp *indexer.ScanCheckpoint, resumed bool, priority scheduler.Priority) {
+	if job := c.job.Load(); job != nil && job.Active() {
+		job.Cancel()
+		c.dropParked()
+	}
+	c.counters.reset()
+	c.cpMu.Lock()
+	c.checkpoint = cp
+	c.cpMu.Unlock()
+	c.saveCheckpoint(indexer.ScanRunning)
+
+	c.namesGen.Store(c.Indexer.Names.NewGeneration())
+	c.job.Store(newJob(resumed, priority))
+	go c.reportProgress()
+	atomic.AddInt64(&c.counters.pending, 1)
+	go c.walk(cp.Root, priority)
 }
-func (c *Coordinator) AddDir() {
-	for folder := range c.UpdateChan {
-		c.watcher.Watcher.Add(folder)
+
+// PauseScan holds the walker and readers of the running scan
+// documents already batched are still committed
+func (c *Coordinator) PauseScan() bool {
+	job := c.job.Load()
+	if job == nil || !job.Pause() {
+		return false
+	}
+	c.triggerProgress()
+	return true
+}
+
+// ResumeScan continues a paused scan
+func (c *Coordinator) ResumeScan() bool {
+	job := c.job.Load()
+	if job == nil || !job.Resume() {
+		return false
 	}
+	c.unpark()
+	c.triggerProgress()
+	return true
+}
+
+// CancelScan stops the running scan
+// files not read yet are skipped and documents already read are committed
+// so the index stays consistent and searchable
+func (c *Coordinator) CancelScan() bool {
+	job := c.job.Load()
+	if job == nil || !job.Cancel() {
+		return false
+	}
+	// parked files are skipped when they run
+	c.unpark()
+	c.triggerProgress()
+	return true
+}
+
+// ScanState returns the state of the last scan
+func (c *Coordinator) ScanState() JobState {
+	job := c.job.Load()
+	if job == nil {
+		return JobIdle
+	}
+	return job.State()
 }
 
 // Close the coordinator and
 // realese resources
 func (c *Coordinator) Shutdown() {
 	c.cancel()
-	close(c.workChan)
-	close(c.fileChan)
-	close(c.docChan)
-	close(c.UpdateChan)
+	if c.mux != nil {
+		c.mux.Unregister(c.root)
+	}
+	c.sched.Drop(c.name)
 	c.wg.Wait()
+	c.saveNames()
 }
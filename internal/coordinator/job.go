@@ -0,0 +1,191 @@
+package coordinator
+
+import (
+	"GoSeek/internal/scheduler"
+	"context"
+	"sync"
+	"time"
+)
+
+type JobState int32
+
+const (
+	JobIdle JobState = iota
+	JobRunning
+	JobPaused
+	JobCancelled
+	JobDone
+)
+
+func (s JobState) String() string {
+	switch s {
+	case JobRunning:
+		return "running"
+	case JobPaused:
+		return "paused"
+	case JobCancelled:
+		return "cancelled"
+	case JobDone:
+		return "done"
+	}
+	return "idle"
+}
+
+// Job is a scan that can be paused, resumed and cancelled
+// walker and readers call wait before every file
+// so they hold in place while the job is paused
+type Job struct {
+	mu          sync.Mutex
+	state       JobState
+	ended       bool          // every file of the job reached a final state
+	resume      chan struct{} // closed while the job is not paused
+	start       time.Time
+	end         time.Time
+	pausedAt    time.Time
+	pausedTotal time.Duration
+
+	// Resumed jobs skip the documents committed before the interruption
+	resumed  bool
+	priority scheduler.Priority
+}
+
+func newJob(resumed bool, priority scheduler.Priority) *Job {
+	resume := make(chan struct{})
+	close(resume)
+	return &Job{
+		state:    JobRunning,
+		resume:   resume,
+		start:    time.Now(),
+		resumed:  resumed,
+		priority: priority,
+	}
+}
+
+func (j *Job) Priority() scheduler.Priority {
+	return j.priority
+}
+
+func (j *Job) State() JobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.state
+}
+
+// Active reports if the job still has files in flight
+func (j *Job) Active() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return !j.ended
+}
+
+// Pause holds the walker and readers until Resume or Cancel
+func (j *Job) Pause() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ended || j.state != JobRunning {
+		return false
+	}
+	j.state = JobPaused
+	j.resume = make(chan struct{})
+	j.pausedAt = time.Now()
+	return true
+}
+
+func (j *Job) Resume() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.state != JobPaused {
+		return false
+	}
+	j.state = JobRunning
+	j.pausedTotal += time.Since(j.pausedAt)
+	close(j.resume)
+	return true
+}
+
+// Cancel stops the job, files not read yet are skipped
+func (j *Job) Cancel() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ended || j.state == JobCancelled {
+		return false
+	}
+	if j.state == JobPaused {
+		j.pausedTotal += time.Since(j.pausedAt)
+		close(j.resume)
+	}
+	j.state = JobCancelled
+	return true
+}
+
+// complete ends the job once nothing is in flight
+// it reports false if the job had already ended
+func (j *Job) complete() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ended {
+		return false
+	}
+	if j.state == JobPaused {
+		j.pausedTotal += time.Since(j.pausedAt)
+		close(j.resume)
+	}
+	if j.state != JobCancelled {
+		j.state = JobDone
+	}
+	j.ended = true
+	j.end = time.Now()
+	return true
+}
+
+// Elapsed returns the running time of the job without the paused time
+func (j *Job) Elapsed() time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ended {
+		return j.end.Sub(j.start) - j.pausedTotal
+	}
+	paused := j.pausedTotal
+	if j.state == JobPaused {
+		paused += time.Since(j.pausedAt)
+	}
+	return time.Since(j.start) - paused
+}
+
+// gate returns the state a task of the job must follow without blocking
+// tasks of an ended job always run
+func (j *Job) gate() JobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ended {
+		return JobDone
+	}
+	return j.state
+}
+
+// wait blocks while the job is paused
+// it returns errScanCancelled if the job is cancelled
+// once the job ended it never blocks so live updates keep flowing
+func (j *Job) wait(ctx context.Context) error {
+	for {
+		j.mu.Lock()
+		state, resume, ended := j.state, j.resume, j.ended
+		j.mu.Unlock()
+
+		if ended {
+			return nil
+		}
+		switch state {
+		case JobCancelled:
+			return errScanCancelled
+		case JobPaused:
+			select {
+			case <-resume:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package coordinator
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a snapshot of the current indexing job
+type Progress struct {
+	Discovered int64 // files found by the walker
+	Read       int64 // files read and sent to the indexers
+	Skipped    int64 // files dropped without reading
+	Failed     int64 // files that could not be read or indexed
+	Batched    int64 // documents added to a batch
+	Committed  int64 // documents flushed to the index
+	Bytes      int64 // content size of the committed documents
+
+	State      JobState
+	Walking    bool          // discovery is still running so Discovered may grow
+	Done       bool          // the job ended, finished or cancelled
+	Elapsed    time.Duration // running time without pauses
+	Throughput float64       // committed documents per second
+	ETA        time.Duration
+}
+
+// Processed returns the number of discovered files that reached a final state
+func (p Progress) Processed() int64 {
+	return p.Committed + p.Skipped + p.Failed
+}
+
+// Fraction returns the completed part of the job in [0, 1]
+func (p Progress) Fraction() float64 {
+	if p.Done {
+		return 1
+	}
+	if p.Discovered == 0 {
+		return 0
+	}
+	f := float64(p.Processed()) / float64(p.Discovered)
+	if f > 1 {
+		f = 1
+	}
+	return f
+}
+
+func (p Progress) String() string {
+	s := fmt.Sprintf("%d/%d files, %d skipped, %d failed, %.1f docs/s",
+		p.Processed(), p.Discovered, p.Skipped, p.Failed, p.Throughput)
+	if p.State == JobPaused || p.State == JobCancelled {
+		return s + " (" + p.State.String() + ")"
+	}
+	if !p.Done && !p.Walking && p.ETA > 0 {
+		s += ", ETA " + p.ETA.Round(time.Second).String()
+	}
+	return s
+}
+
+// jobCounters holds the exact accounting of a job
+// every path sent on fileChan is counted in pending
+// until it is committed, skipped or failed
+type jobCounters struct {
+	discovered int64
+	read       int64
+	skipped    int64
+	failed     int64
+	batched    int64
+	committed  int64
+	bytes      int64
+
+	pending int64
+	walking int32
+}
+
+func (jc *jobCounters) reset() {
+	atomic.StoreInt64(&jc.discovered, 0)
+	atomic.StoreInt64(&jc.read, 0)
+	atomic.StoreInt64(&jc.skipped, 0)
+	atomic.StoreInt64(&jc.failed, 0)
+	atomic.StoreInt64(&jc.batched, 0)
+	atomic.StoreInt64(&jc.committed, 0)
+	atomic.StoreInt64(&jc.bytes, 0)
+}
+
+func (jc *jobCounters) snapshot(elapsed time.Duration) Progress {
+	p := Progress{
+		Discovered: atomic.LoadInt64(&jc.discovered),
+		Read:       atomic.LoadInt64(&jc.read),
+		Skipped:    atomic.LoadInt64(&jc.skipped),
+		Failed:     atomic.LoadInt64(&jc.failed),
+		Batched:    atomic.LoadInt64(&jc.batched),
+		Committed:  atomic.LoadInt64(&jc.committed),
+		Bytes:      atomic.LoadInt64(&jc.bytes),
+		Walking:    atomic.LoadInt32(&jc.walking) > 0,
+		Elapsed:    elapsed,
+	}
+	if secs := p.Elapsed.Seconds(); secs > 0 {
+		p.Throughput = float64(p.Committed) / secs
+	}
+	if remaining := p.Discovered - p.Processed(); remaining > 0 && p.Throughput > 0 {
+		p.ETA = time.Duration(float64(remaining) / p.Throughput * float64(time.Second))
+	}
+	return p
+}
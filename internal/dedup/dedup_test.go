@@ -0,0 +1,92 @@
+package dedup
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSimHash(t *testing.T) {
+	text := "the quick brown fox jumps over the lazy dog while the cat sleeps in the warm sun all day long"
+	a, ok := SimHash(text)
+	if !ok {
+		t.Fatal("no SimHash for a long text")
+	}
+	if b, _ := SimHash(strings.ToUpper(text)); b != a {
+		t.Errorf("the case changes the SimHash")
+	}
+	edited, _ := SimHash(strings.Replace(text, "warm", "hot", 1))
+	other, _ := SimHash("completely different words about invoices budgets and quarterly plans for the next year of sales")
+	if Distance(a, edited) >= Distance(a, other) {
+		t.Errorf("an edited copy is %d bits away, another text %d", Distance(a, edited), Distance(a, other))
+	}
+	if _, ok := SimHash("too few words"); ok {
+		t.Error("a SimHash for a text under MinWords")
+	}
+}
+
+func TestFormatParse(t *testing.T) {
+	for _, h := range []uint64{0, 1, 0xdeadbeef, ^uint64(0)} {
+		s := Format(h)
+		if len(s) != 16 {
+			t.Errorf("Format(%x) = %q, not 16 digits", h, s)
+		}
+		if got, ok := Parse(s); !ok || got != h {
+			t.Errorf("Parse(%q) = %x, %v", s, got, ok)
+		}
+	}
+}
+
+func TestNear(t *testing.T) {
+	a := uint64(0xf0f0f0f0f0f0f0f0)
+	b := a ^ 0b101         // 2 bits from a
+	c := b ^ 0b110000      // 2 bits from b, 4 from a
+	far := ^a              // 64 bits from a
+	lone := a ^ 0xffff0000 // 16 bits from a
+	clusters := Near([]uint64{a, b, c, far, lone}, 2)
+	// a, b and c are linked through b
+	root := min(a, b, c)
+	for _, h := range []uint64{a, b, c} {
+		if clusters[h] != root {
+			t.Errorf("cluster of %x = %x, want %x", h, clusters[h], root)
+		}
+	}
+	for _, h := range []uint64{far, lone} {
+		if clusters[h] != h {
+			t.Errorf("%x is clustered with %x", h, clusters[h])
+		}
+	}
+	// no bits allowed keeps every hash alone
+	for h, root := range Near([]uint64{a, b, c}, 0) {
+		if h != root {
+			t.Errorf("%x is clustered with %x without bits allowed", h, root)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	type file struct {
+		name string
+		keys []string
+	}
+	files := []file{
+		{"a", []string{"hash:1"}},
+		{"b", []string{"hash:2", "simhash:x"}},
+		{"c", []string{"hash:1", "simhash:x"}}, // joins a and b
+		{"d", []string{"hash:3"}},
+		{"e", nil},
+		{"f", []string{"hash:3"}},
+	}
+	groups := Group(files, func(f file) []string { return f.keys })
+	var got []string
+	for _, g := range groups {
+		var names []string
+		for _, f := range g {
+			names = append(names, f.name)
+		}
+		got = append(got, strings.Join(names, ""))
+	}
+	if want := []string{"abc", "df", "e"}; !slices.Equal(got, want) {
+		t.Errorf("Group = %v, want %v", got, want)
+	}
+}
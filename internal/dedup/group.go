@@ -0,0 +1,104 @@
+package dedup
+
+import "cmp"
+
+// Near links the hashes at most maxBits bits apart, and the hashes linked
+// to those, and returns the smallest hash of its cluster for every hash
+// two hashes maxBits apart agree on one of maxBits+1 bands of bits at least,
+// so only the hashes sharing a band are compared
+func Near(hashes []uint64, maxBits int) map[uint64]uint64 {
+	maxBits = min(max(maxBits, 0), 63)
+	u := newUnion(hashes)
+	bands := maxBits + 1
+	for band := range bands {
+		lo, hi := 64*band/bands, 64*(band+1)/bands
+		mask := uint64(1)<<(hi-lo) - 1
+		if hi-lo == 64 {
+			mask = ^uint64(0)
+		}
+		buckets := make(map[uint64][]uint64)
+		for _, h := range hashes {
+			key := h >> lo & mask
+			buckets[key] = append(buckets[key], h)
+		}
+		for _, bucket := range buckets {
+			for i, a := range bucket {
+				for _, b := range bucket[i+1:] {
+					if Distance(a, b) <= maxBits {
+						u.join(a, b)
+					}
+				}
+			}
+		}
+	}
+	clusters := make(map[uint64]uint64, len(hashes))
+	for _, h := range hashes {
+		clusters[h] = u.find(h)
+	}
+	return clusters
+}
+
+// Group puts the items sharing a key together, with the items sharing
+// a key with those, the groups and their items in the order of items
+func Group[T any](items []T, keys func(item T) []string) [][]T {
+	ids := make([]int, len(items))
+	for i := range items {
+		ids[i] = i
+	}
+	u := newUnion(ids)
+	first := make(map[string]int)
+	for i, item := range items {
+		for _, key := range keys(item) {
+			if j, ok := first[key]; ok {
+				u.join(i, j)
+			} else {
+				first[key] = i
+			}
+		}
+	}
+	var groups [][]T
+	group := make(map[int]int) // group of the first item of a set
+	for i, item := range items {
+		root := u.find(i)
+		g, ok := group[root]
+		if !ok {
+			g = len(groups)
+			group[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], item)
+	}
+	return groups
+}
+
+// union is a disjoint set, the smallest key stands for its set
+type union[K cmp.Ordered] struct {
+	parent map[K]K
+}
+
+func newUnion[K cmp.Ordered](keys []K) *union[K] {
+	u := &union[K]{parent: make(map[K]K, len(keys))}
+	for _, k := range keys {
+		u.parent[k] = k
+	}
+	return u
+}
+
+func (u *union[K]) find(k K) K {
+	for u.parent[k] != k {
+		u.parent[k] = u.parent[u.parent[k]]
+		k = u.parent[k]
+	}
+	return k
+}
+
+func (u *union[K]) join(a, b K) {
+	ra, rb := u.find(a), u.find(b)
+	if ra == rb {
+		return
+	}
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	u.parent[rb] = ra
+}
@@ -0,0 +1,73 @@
+// Package dedup finds the copies of a file among the indexed ones,
+// the exact copies by the hash of their content and the near ones,
+// an edited copy or a copy saved in another format, by the SimHash
+// of their words.
+package dedup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ShingleWords are the words hashed together, so a text is
+// told apart by the order of its words and not only by them
+const ShingleWords = 3
+
+// MinWords is the fewest words of a text with a SimHash
+// the signatures of a few words collide too easily
+const MinWords = 8
+
+// SimHash is the 64 bit SimHash of the shingles of the text
+// texts sharing most of their shingles have close hashes
+// ok is false for texts under MinWords words
+func SimHash(text string) (hash uint64, ok bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < MinWords {
+		return 0, false
+	}
+	var weights [64]int
+	h := fnv.New64a()
+	for i := 0; i+ShingleWords <= len(words); i++ {
+		h.Reset()
+		for _, word := range words[i : i+ShingleWords] {
+			h.Write([]byte(word))
+			h.Write([]byte{0})
+		}
+		sum := h.Sum64()
+		for b := range 64 {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+	for b, w := range weights {
+		if w > 0 {
+			hash |= 1 << b
+		}
+	}
+	return hash, true
+}
+
+// Distance is the number of bits two hashes differ in
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Format writes a hash as the 16 hex digits it is indexed as
+func Format(hash uint64) string {
+	s := strconv.FormatUint(hash, 16)
+	return strings.Repeat("0", 16-len(s)) + s
+}
+
+// Parse reads a hash written by Format
+func Parse(s string) (uint64, bool) {
+	hash, err := strconv.ParseUint(s, 16, 64)
+	return hash, err == nil
+}
@@ -0,0 +1,148 @@
+// Package embed turns texts in vectors whose closeness follows
+// the closeness of their meaning, for the similar meaning search.
+// Everything runs locally on the CPU, an embedder never calls a
+// network service.
+package embed
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Embedder computes the embedding of a text
+// the vectors have Dims values and a length of 1
+// Embed is called by several readers at once
+type Embedder interface {
+	Name() string
+	Dims() int
+	Embed(text string) []float32
+}
+
+// Factory makes an embedder, arg is what follows the colon
+// in names like "wordvec:/models/glove.txt"
+type Factory func(arg string) (Embedder, error)
+
+var (
+	factoriesLock sync.Mutex
+	factories     = map[string]Factory{}
+	// embedders are shared by the indexes using the same name
+	embedders = map[string]Embedder{}
+)
+
+// Register makes an embedder available to New under name
+func Register(name string, f Factory) {
+	factoriesLock.Lock()
+	factories[name] = f
+	factoriesLock.Unlock()
+}
+
+// Names lists the registered embedders
+func Names() []string {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns the embedder of name, "kind" or "kind:arg"
+func New(name string) (Embedder, error) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	if e, ok := embedders[name]; ok {
+		return e, nil
+	}
+	kind, arg, _ := strings.Cut(name, ":")
+	f, ok := factories[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown embedder %q", kind)
+	}
+	e, err := f(arg)
+	if err != nil {
+		return nil, err
+	}
+	embedders[name] = e
+	return e, nil
+}
+
+const (
+	// ChunkWords is the length of the pieces of a document embedded apart
+	// a long document is about several things, its chunks find each of them
+	ChunkWords = 200
+	// ChunkOverlap words are repeated from a chunk to the next one
+	// so a sentence cut in two is whole in one of them
+	ChunkOverlap = 40
+	// MaxChunks of a document, the end of bigger ones is not embedded
+	MaxChunks = 64
+)
+
+// Chunk splits text in pieces of size words overlapping by overlap words
+func Chunk(text string, size, overlap int) []string {
+	words := strings.FieldsFunc(text, unicode.IsSpace)
+	if len(words) == 0 {
+		return nil
+	}
+	step := max(size-overlap, 1)
+	var chunks []string
+	for start := 0; len(chunks) < MaxChunks; start += step {
+		end := min(start+size, len(words))
+		chunks = append(chunks, strings.Join(words[start:end], " "))
+		if end == len(words) {
+			break
+		}
+	}
+	return chunks
+}
+
+// EmbedChunks computes the embeddings of the chunks of a document
+// chunks without a word the embedder knows have none
+func EmbedChunks(e Embedder, text string) [][]float32 {
+	var vectors [][]float32
+	for _, chunk := range Chunk(text, ChunkWords, ChunkOverlap) {
+		if vec := e.Embed(chunk); !zero(vec) {
+			vectors = append(vectors, vec)
+		}
+	}
+	return vectors
+}
+
+func zero(vec []float32) bool {
+	for _, v := range vec {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize scales vec to a length of 1, a zero vector is kept
+func Normalize(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := float32(1 / math.Sqrt(sum))
+	for i := range vec {
+		vec[i] *= norm
+	}
+	return vec
+}
+
+// Cosine is the similarity of two normalized vectors, 1 for the same direction
+func Cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
@@ -0,0 +1,106 @@
+package embed
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestChunk(t *testing.T) {
+	words := make([]string, 25)
+	for i := range words {
+		words[i] = string(rune('a' + i))
+	}
+	text := strings.Join(words, "  \n")
+	chunks := Chunk(text, 10, 4)
+	want := []string{
+		"a b c d e f g h i j",
+		"g h i j k l m n o p",
+		"m n o p q r s t u v",
+		"s t u v w x y",
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Chunk gave %d chunks %q, want %d", len(chunks), chunks, len(want))
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Errorf("chunk %d = %q, want %q", i, chunks[i], want[i])
+		}
+	}
+	if got := Chunk(" \n\t", 10, 4); got != nil {
+		t.Errorf("Chunk of spaces = %q", got)
+	}
+	if got := Chunk("one two", 10, 4); len(got) != 1 || got[0] != "one two" {
+		t.Errorf("Chunk of a short text = %q", got)
+	}
+	// an overlap as long as the chunk still moves forward
+	if got := Chunk("a b c", 2, 2); len(got) != 2 {
+		t.Errorf("Chunk with a full overlap = %q", got)
+	}
+	long := strings.Repeat("word ", ChunkWords*MaxChunks)
+	if got := Chunk(long, ChunkWords, ChunkOverlap); len(got) != MaxChunks {
+		t.Errorf("Chunk of a long text gave %d chunks, want %d", len(got), MaxChunks)
+	}
+}
+
+func newHashing(t *testing.T) *Hashing {
+	t.Helper()
+	h, err := NewHashing(HashingDims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestHashingDeterministic(t *testing.T) {
+	text := "The invoices of the third quarter are late"
+	a, b := newHashing(t).Embed(text), newHashing(t).Embed(text)
+	if len(a) != HashingDims {
+		t.Fatalf("Embed gave %d dimensions, want %d", len(a), HashingDims)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Embed differs at %d: %v and %v", i, a[i], b[i])
+		}
+	}
+}
+
+func TestHashingNormalized(t *testing.T) {
+	h := newHashing(t)
+	for _, text := range []string{"budget", "the running runners ran", strings.Repeat("invoice ", 50)} {
+		var sum float64
+		for _, v := range h.Embed(text) {
+			sum += float64(v) * float64(v)
+		}
+		if math.Abs(math.Sqrt(sum)-1) > 1e-5 {
+			t.Errorf("Embed(%q) has a length of %v", text, math.Sqrt(sum))
+		}
+	}
+	// nothing to embed, the zero vector is kept
+	for _, v := range h.Embed("the and of") {
+		if v != 0 {
+			t.Fatal("stop words only have an embedding")
+		}
+	}
+}
+
+func TestHashingCloseness(t *testing.T) {
+	h := newHashing(t)
+	query := h.Embed("paying the invoices")
+	close := h.Embed("the invoice was paid late")
+	far := h.Embed("a walk in the mountains")
+	if Cosine(query, close) <= Cosine(query, far) {
+		t.Errorf("similar text %v is not closer than another one %v", Cosine(query, close), Cosine(query, far))
+	}
+}
+
+func TestEmbedChunks(t *testing.T) {
+	h := newHashing(t)
+	if got := EmbedChunks(h, "the and of"); got != nil {
+		t.Errorf("chunks without a known word have embeddings")
+	}
+	text := strings.Repeat("budget plans ", ChunkWords)
+	if got := EmbedChunks(h, text); len(got) != len(Chunk(text, ChunkWords, ChunkOverlap)) {
+		t.Errorf("EmbedChunks gave %d vectors", len(got))
+	}
+}
@@ -0,0 +1,87 @@
+package embed
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/registry"
+)
+
+// HashingName is the embedder of the indexes asking for one without a model
+const HashingName = "hashing"
+
+// HashingDims are the dimensions of the hashing embedder by default
+const HashingDims = 256
+
+func init() {
+	Register(HashingName, func(arg string) (Embedder, error) {
+		dims := HashingDims
+		if arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 16 {
+				return nil, fmt.Errorf("bad dimensions %q for the hashing embedder", arg)
+			}
+			dims = n
+		}
+		return NewHashing(dims)
+	})
+}
+
+// Hashing embeds the stemmed words and their letter trigrams
+// of a text by the feature hashing trick, so texts sharing words
+// or forms of a word are close
+// it is deterministic and needs no model, a stand in for a real one
+// which plugs in through Register
+type Hashing struct {
+	dims     int
+	analyzer analysis.Analyzer
+}
+
+// NewHashing returns a hashing embedder of dims dimensions
+func NewHashing(dims int) (*Hashing, error) {
+	// english stemming and stop words, like the "en" content analyzer
+	analyzer, err := registry.NewCache().AnalyzerNamed(en.AnalyzerName)
+	if err != nil {
+		return nil, err
+	}
+	return &Hashing{dims: dims, analyzer: analyzer}, nil
+}
+
+func (h *Hashing) Name() string { return HashingName }
+
+func (h *Hashing) Dims() int { return h.dims }
+
+func (h *Hashing) Embed(text string) []float32 {
+	counts := make(map[string]int)
+	for _, tok := range h.analyzer.Analyze([]byte(text)) {
+		counts[string(tok.Term)]++
+	}
+	vec := make([]float32, h.dims)
+	for word, n := range counts {
+		// a word said again adds less and less
+		weight := float32(1 + math.Log(float64(n)))
+		h.add(vec, "w:"+word, weight)
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			h.add(vec, string(padded[i:i+3]), weight/2)
+		}
+	}
+	return Normalize(vec)
+}
+
+// add puts the feature in a dimension with a sign from its hash
+// so the collisions cancel out instead of piling up
+func (h *Hashing) add(vec []float32, feature string, weight float32) {
+	hash := fnv.New64a()
+	hash.Write([]byte(feature))
+	sum := hash.Sum64()
+	i := int(sum % uint64(h.dims))
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vec[i] += weight
+}
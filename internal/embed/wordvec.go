@@ -0,0 +1,99 @@
+package embed
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WordVectorsName embeds with a local file of word vectors, named
+// like "wordvec:/path/to/glove.6B.100d.txt"
+const WordVectorsName = "wordvec"
+
+func init() {
+	Register(WordVectorsName, func(path string) (Embedder, error) {
+		if path == "" {
+			return nil, fmt.Errorf("the wordvec embedder needs the path of a vectors file")
+		}
+		return LoadWordVectors(path)
+	})
+}
+
+// WordVectors averages pretrained vectors of the words of a text,
+// words of close meaning have close vectors so the texts do too
+// the file is in the text format of GloVe and fastText, a word
+// and its values on each line, fastText has a header line
+type WordVectors struct {
+	path  string
+	dims  int
+	words map[string][]float32
+}
+
+// LoadWordVectors reads a vectors file
+func LoadWordVectors(path string) (*WordVectors, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	wv := &WordVectors{path: path, words: make(map[string][]float32)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) <= 2 {
+			continue // the fastText header, "count dims"
+		}
+		if wv.dims == 0 {
+			wv.dims = len(fields) - 1
+		}
+		if len(fields)-1 != wv.dims {
+			return nil, fmt.Errorf("%s:%d: %d values instead of %d", path, line, len(fields)-1, wv.dims)
+		}
+		vec := make([]float32, wv.dims)
+		for i, f := range fields[1:] {
+			v, err := strconv.ParseFloat(f, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			vec[i] = float32(v)
+		}
+		wv.words[fields[0]] = vec
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if wv.dims == 0 {
+		return nil, fmt.Errorf("%s has no word vectors", path)
+	}
+	return wv, nil
+}
+
+func (wv *WordVectors) Name() string { return WordVectorsName + ":" + wv.path }
+
+func (wv *WordVectors) Dims() int { return wv.dims }
+
+func (wv *WordVectors) Embed(text string) []float32 {
+	counts := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		counts[w]++
+	}
+	vec := make([]float32, wv.dims)
+	for w, n := range counts {
+		wordVec, ok := wv.words[w]
+		if !ok {
+			continue
+		}
+		weight := float32(1 + math.Log(float64(n)))
+		for i, v := range wordVec {
+			vec[i] += v * weight
+		}
+	}
+	return Normalize(vec)
+}
@@ -1,7 +1,14 @@
 package fileprocessor
 
 import (
+	"GoSeek/internal/code"
+	"GoSeek/internal/dedup"
+	"GoSeek/internal/embed"
+	"GoSeek/internal/governor"
+	"GoSeek/internal/metadata"
 	"GoSeek/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
@@ -9,8 +16,6 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
-	"sync/atomic"
-	"time"
 )
 
 type FileProcessor struct {
@@ -18,6 +23,7 @@ type FileProcessor struct {
 	bufferPool        sync.Pool
 	builderPool       sync.Pool
 	base              string
+	embedder          embed.Embedder // nil when the index has no embeddings
 }
 
 // NewWalker returns a pointer to Walker Instance standing
@@ -42,6 +48,11 @@ func NewFileProcessor(base string, allowedExtensions map[string]bool, chunksize,
 	return fp
 }
 
+// SetEmbedder makes Read compute the embeddings of the documents
+func (fp *FileProcessor) SetEmbedder(e embed.Embedder) {
+	fp.embedder = e
+}
+
 func (fp *FileProcessor) getBuffer() *[]byte {
 	return fp.bufferPool.Get().(*[]byte)
 }
@@ -62,73 +73,117 @@ func (fp *FileProcessor) putBuilder(b *strings.Builder) {
 // Walk is the main method of the walker instance
 // It starts to traverse the system using filepath.WalkDir func
 // It is also the producer func to Index consumer
+// onFile is called for every file with an allowed extension,
+// a non nil error from it stops the walk and is returned
+// onDir is called for every folder
+// onName is called for every file whatever its extension, it can be nil
 
 // TODO:
 // Try using fastwalk module (It is stated as being much faster than filepath.WalkDir)
 
-func (fp *FileProcessor) Walk(filePath string, fileChan chan<- string, updateChan chan string) {
-	// go StreamToIndex(w.chunkSize, w.numWorkers, fileChan, docChan)
-	err := filepath.WalkDir(filePath, func(path string, d fs.DirEntry, err error) error {
+func (fp *FileProcessor) Walk(filePath string, onFile func(path string) error, onDir func(path string), onName func(path string)) error {
+	return filepath.WalkDir(filePath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			// fmt.Println("Error opening file/folder at: ", err)
 			return nil
 		}
 		// println("Walker", "     ", path)
 		if d.IsDir() {
-			updateChan <- path
+			onDir(path)
 			return nil
 		}
-		ext := filepath.Ext(path)
-		if _, ok := fp.allowedExtensions[ext]; !ok {
+		if onName != nil {
+			onName(path)
+		}
+		if !fp.Allowed(path) {
 			return nil
 		}
-		fileChan <- path
-		return nil
+		return onFile(path)
 	})
-	if err != nil {
-		fmt.Printf("Error walking the directory: %v\n", err)
-	}
 }
 
-func (fp *FileProcessor) Read(filePath string, info os.FileInfo, docChan chan<- *models.Document, filesRead *int32) error {
+// Allowed reports if the file at path has one of the indexed extensions
+func (fp *FileProcessor) Allowed(path string) bool {
+	return fp.allowedExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// Read loads the content of the file as a document
+// with the hashes telling its copies, see models.Document
+func (fp *FileProcessor) Read(filePath string, info os.FileInfo) (*models.Document, error) {
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		fmt.Printf("Error in Opening file %v", err)
-		return nil
+		return nil, fmt.Errorf("error in opening file: %w", err)
 	}
+	defer file.Close()
 	// fmt.Println("Reader: ", file.Name())
 	content := fp.getBuilder()
+	defer fp.putBuilder(content)
 
-	// fileHash := xxhash.New()
-	buffer := *fp.getBuffer()
+	fileHash := sha256.New()
+	buffer := fp.getBuffer()
+	defer fp.putBuffer(buffer)
 	// println("Reader    ", filePath)
 	for {
-		n, err := file.Read(buffer)
-		if err != nil && err != io.EOF {
-			print(err)
-		}
+		n, err := file.Read(*buffer)
+		governor.Default().WaitRead(n)
+		fileHash.Write((*buffer)[:n])
+		content.Write((*buffer)[:n])
 		if err == io.EOF {
 			break
 		}
-		// fileHash.Write(buffer[:n])
-		content.Write(buffer[:n])
+		if err != nil {
+			return nil, fmt.Errorf("error in reading file: %w", err)
+		}
 	}
-	// currHash := fileHash.Sum64()
-
 	ext := filepath.Ext(filePath)
-	modtime := info.ModTime().Format(time.RFC1123)
+	modtime := info.ModTime()
 	size := info.Size()
-	atomic.AddInt32(filesRead, 1)
+	relPath := fp.RelPath(filePath)
+	// println(filePath, "    ", relPath)
+	doc := models.NewDocument(relPath, size, modtime, ext, content.String())
+	// empty files are not copies of each other
+	if content.Len() > 0 {
+		doc.Hash = hex.EncodeToString(fileHash.Sum(nil))
+	}
+	metadata.Extract(doc, filePath, info)
+	// documents and photos are indexed by their metadata and name,
+	// their bytes are no words to search, compare or embed
+	if binary(doc) {
+		doc.Content = ""
+		return doc, nil
+	}
+	if simHash, ok := dedup.SimHash(doc.Content); ok {
+		doc.SimHash = dedup.Format(simHash)
+	}
+	// embedded here on the pool workers, the indexer of the batches is alone
+	if fp.embedder != nil {
+		doc.Vectors = embed.EmbedChunks(fp.embedder, doc.Content)
+	}
+	if code.Supported(ext) {
+		if parsed := code.Parse(filePath, doc.Content); parsed != nil {
+			doc.Symbols = parsed.SymbolNames()
+			doc.Comments = parsed.Comments
+			doc.Strings = parsed.Strings
+		}
+	}
+	return doc, nil
+}
+
+// binary reports if the content of doc is not text, a known format
+// or a file with a NUL byte in its first KB
+func binary(doc *models.Document) bool {
+	head := doc.Content[:min(len(doc.Content), 1024)]
+	return metadata.Binary(doc.Extension) || strings.IndexByte(head, 0) >= 0
+}
+
+// RelPath returns the path relative to the base folder
+// which is the id of the document in the index
+func (fp *FileProcessor) RelPath(filePath string) string {
 	relPath, err := filepath.Rel(fp.base, filePath)
 	if err != nil {
 		println(err.Error())
-		relPath = filePath
+		return filePath
 	}
-	// println(filePath, "    ", relPath)
-	docChan <- models.NewDocument(relPath, size, modtime, ext, content.String())
-	fp.putBuffer(&buffer)
-	fp.putBuilder(content)
-	file.Close() // DONE
-	return nil
+	return relPath
 }
@@ -0,0 +1,299 @@
+package governor
+
+import (
+	"GoSeek/config"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limits of the resources used by background indexing
+type Limits struct {
+	MaxInFlightMemory int64         // content bytes read but not committed yet
+	ReadBandwidth     int64         // disk read bytes per second, 0 is unlimited
+	Readers           int           // concurrent readers while the machine is idle
+	BackgroundReaders int           // concurrent readers while the user is active or on battery
+	UserIdleTimeout   time.Duration // the user is active for this long after the last input
+	Niceness          int           // scheduling priority of the process, 0 keeps the default
+}
+
+// Usage is a snapshot of the resources in use
+type Usage struct {
+	InFlightMemory int64
+	ActiveReaders  int
+	ReaderLimit    int
+	ReadRate       float64 // bytes read per second over the last window
+	UserActive     bool
+	OnBattery      bool
+	Limits         Limits
+}
+
+func (u Usage) String() string {
+	s := fmt.Sprintf("Memory %v/%v MB, readers %v/%v, read %.1f MB/s",
+		u.InFlightMemory/(1024*1024), u.Limits.MaxInFlightMemory/(1024*1024),
+		u.ActiveReaders, u.ReaderLimit, u.ReadRate/(1024*1024))
+	if u.OnBattery {
+		s += ", on battery"
+	}
+	if u.UserActive {
+		s += ", user active"
+	}
+	return s
+}
+
+// Governor throttles the indexing work of all coordinators
+// readers ask it for a slot, for the memory of the file they read
+// and for read bandwidth, then give the memory back once the document is committed
+type Governor struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limits Limits
+
+	inFlight     int64
+	readers      int
+	lastActivity time.Time
+	onBattery    bool
+
+	// read bandwidth token bucket
+	tokens     float64
+	lastRefill time.Time
+
+	// read rate measurement
+	windowBytes int64
+	windowStart time.Time
+	readRate    float64
+}
+
+const powerCheckInterval = 30 * time.Second
+
+var (
+	defaultGovernor *Governor
+	defaultOnce     sync.Once
+)
+
+// Default returns the governor shared by all coordinators
+// built from the limits of the user
+func Default() *Governor {
+	defaultOnce.Do(func() {
+		l, err := config.LoadLimits()
+		if err != nil {
+			fmt.Printf("Error loading the resource limits: %v\n", err)
+		}
+		defaultGovernor = New(LimitsOf(l))
+	})
+	return defaultGovernor
+}
+
+// LimitsOf are the limits of the user settings
+func LimitsOf(l *config.Limits) Limits {
+	return Limits{
+		MaxInFlightMemory: l.MemoryMB * 1024 * 1024,
+		ReadBandwidth:     l.ReadMBPerSec * 1024 * 1024,
+		Readers:           l.Readers,
+		BackgroundReaders: l.BackgroundReaders,
+		UserIdleTimeout:   config.LoadGlobalConfig().UserIdleTimeout,
+		Niceness:          l.Niceness,
+	}
+}
+
+func New(limits Limits) *Governor {
+	g := &Governor{
+		lastRefill:  time.Now(),
+		windowStart: time.Now(),
+	}
+	g.cond = sync.NewCond(&g.mu)
+	g.SetLimits(limits)
+	go g.watchPower()
+	return g
+}
+
+// SetLimits changes the limits, waiting readers are re-evaluated
+func (g *Governor) SetLimits(limits Limits) {
+	if limits.Readers < 1 {
+		limits.Readers = 1
+	}
+	if limits.BackgroundReaders < 1 || limits.BackgroundReaders > limits.Readers {
+		limits.BackgroundReaders = limits.Readers
+	}
+	g.mu.Lock()
+	niceChanged := limits.Niceness != g.limits.Niceness
+	g.limits = limits
+	g.tokens = float64(limits.ReadBandwidth)
+	g.cond.Broadcast()
+	g.mu.Unlock()
+
+	if niceChanged && limits.Niceness != 0 {
+		if err := setNiceness(limits.Niceness); err != nil {
+			fmt.Printf("Error lowering process priority: %v\n", err)
+		}
+	}
+}
+
+func (g *Governor) Limits() Limits {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.limits
+}
+
+// Usage returns the current resources in use
+func (g *Governor) Usage() Usage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rate := g.readRate
+	if elapsed := time.Since(g.windowStart).Seconds(); elapsed > 1 {
+		rate = float64(g.windowBytes) / elapsed
+	}
+	return Usage{
+		InFlightMemory: g.inFlight,
+		ActiveReaders:  g.readers,
+		ReaderLimit:    g.readerLimit(),
+		ReadRate:       rate,
+		UserActive:     g.userActive(),
+		OnBattery:      g.onBattery,
+		Limits:         g.limits,
+	}
+}
+
+// NoteUserActivity is called on user input
+// indexing slows down until the user is idle again
+func (g *Governor) NoteUserActivity() {
+	g.mu.Lock()
+	g.lastActivity = time.Now()
+	g.mu.Unlock()
+}
+
+func (g *Governor) userActive() bool {
+	return !g.lastActivity.IsZero() && time.Since(g.lastActivity) < g.limits.UserIdleTimeout
+}
+
+func (g *Governor) readerLimit() int {
+	if g.onBattery || g.userActive() {
+		return g.limits.BackgroundReaders
+	}
+	return g.limits.Readers
+}
+
+// AcquireReader blocks until a reader slot is free
+func (g *Governor) AcquireReader(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for g.readers >= g.readerLimit() {
+		if err := g.waitLocked(ctx); err != nil {
+			return err
+		}
+	}
+	g.readers++
+	return nil
+}
+
+func (g *Governor) ReleaseReader() {
+	g.mu.Lock()
+	g.readers--
+	g.cond.Broadcast()
+	g.mu.Unlock()
+}
+
+// MemoryCost returns the in flight memory accounted for a file of that size
+// files bigger than the whole budget are let through alone
+func (g *Governor) MemoryCost(size int64) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.limits.MaxInFlightMemory > 0 && size > g.limits.MaxInFlightMemory {
+		return g.limits.MaxInFlightMemory
+	}
+	return size
+}
+
+// AcquireMemory blocks until n bytes fit in the in flight budget
+func (g *Governor) AcquireMemory(ctx context.Context, n int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for g.limits.MaxInFlightMemory > 0 && g.inFlight > 0 && g.inFlight+n > g.limits.MaxInFlightMemory {
+		if err := g.waitLocked(ctx); err != nil {
+			return err
+		}
+	}
+	g.inFlight += n
+	return nil
+}
+
+func (g *Governor) ReleaseMemory(n int64) {
+	g.mu.Lock()
+	g.inFlight -= n
+	g.cond.Broadcast()
+	g.mu.Unlock()
+}
+
+// UnderPressure reports if the in flight memory is close to the budget
+// indexers flush their batches early to give it back
+func (g *Governor) UnderPressure() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.limits.MaxInFlightMemory > 0 && g.inFlight*10 >= g.limits.MaxInFlightMemory*9
+}
+
+// WaitRead blocks until n bytes can be read within the read bandwidth
+func (g *Governor) WaitRead(n int) {
+	g.mu.Lock()
+	g.windowBytes += int64(n)
+	if elapsed := time.Since(g.windowStart); elapsed >= 5*time.Second {
+		g.readRate = float64(g.windowBytes) / elapsed.Seconds()
+		g.windowBytes = 0
+		g.windowStart = time.Now()
+	}
+	rate := float64(g.limits.ReadBandwidth)
+	if rate <= 0 {
+		g.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	g.tokens += now.Sub(g.lastRefill).Seconds() * rate
+	if g.tokens > rate {
+		g.tokens = rate
+	}
+	g.lastRefill = now
+	g.tokens -= float64(n)
+	var delay time.Duration
+	if g.tokens < 0 {
+		delay = time.Duration(-g.tokens / rate * float64(time.Second))
+	}
+	g.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// waitLocked waits for a broadcast or the end of ctx
+// the limits depend on time so waiters wake up every second to re-check
+func (g *Governor) waitLocked(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		g.mu.Lock()
+		g.cond.Broadcast()
+		g.mu.Unlock()
+	})
+	defer stop()
+	timer := time.AfterFunc(time.Second, func() {
+		g.mu.Lock()
+		g.cond.Broadcast()
+		g.mu.Unlock()
+	})
+	defer timer.Stop()
+	g.cond.Wait()
+	return ctx.Err()
+}
+
+func (g *Governor) watchPower() {
+	for {
+		battery := onBattery()
+		g.mu.Lock()
+		g.onBattery = battery
+		g.cond.Broadcast()
+		g.mu.Unlock()
+		time.Sleep(powerCheckInterval)
+	}
+}
@@ -0,0 +1,33 @@
+package governor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// onBattery reports if the machine runs on battery
+// it is true when there is a battery and no mains supply is online
+func onBattery() bool {
+	supplies, err := filepath.Glob("/sys/class/power_supply/*")
+	if err != nil || len(supplies) == 0 {
+		return false
+	}
+	hasBattery := false
+	for _, supply := range supplies {
+		kind, err := os.ReadFile(filepath.Join(supply, "type"))
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(string(kind)) {
+		case "Mains", "USB":
+			online, err := os.ReadFile(filepath.Join(supply, "online"))
+			if err == nil && strings.TrimSpace(string(online)) == "1" {
+				return false
+			}
+		case "Battery":
+			hasBattery = true
+		}
+	}
+	return hasBattery
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package governor
+
+// onBattery is not supported on this platform
+func onBattery() bool {
+	return false
+}
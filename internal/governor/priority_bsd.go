@@ -0,0 +1,10 @@
+//go:build darwin || freebsd
+
+package governor
+
+import "syscall"
+
+// setNiceness lowers the scheduling priority of the process
+func setNiceness(nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, 0, nice)
+}
@@ -0,0 +1,27 @@
+package governor
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// setNiceness lowers the scheduling priority of the process
+// linux applies it per thread so every thread of the process is updated
+// threads created later inherit it
+func setNiceness(nice int) error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return syscall.Setpriority(syscall.PRIO_PROCESS, 0, nice)
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, nice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
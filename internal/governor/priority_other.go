@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package governor
+
+import "errors"
+
+// setNiceness is not supported on this platform
+func setNiceness(nice int) error {
+	return errors.New("process priority is not supported on this platform")
+}
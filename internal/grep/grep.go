@@ -0,0 +1,162 @@
+// Package grep searches file contents with a regular expression.
+// The index narrows the files down with the words the expression
+// needs, then only those files are scanned line by line.
+package grep
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"regexp/syntax"
+
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Pattern is a compiled grep expression
+type Pattern struct {
+	Expr       string
+	IgnoreCase bool
+	re         *regexp.Regexp
+	need       *need
+}
+
+// Match is a line matching the pattern
+type Match struct {
+	Path   string
+	Line   int    // 1 based line number
+	Column int    // 1 based byte column of the match in the line
+	Offset int64  // byte offset of the match in the file
+	Length int    // bytes of the match
+	Text   string // the whole line without its end
+}
+
+// maxLine is the longest line scanned, longer ones are cut
+const maxLine = 1 << 20
+
+// Compile parses expr, matches are case sensitive unless ignoreCase
+func Compile(expr string, ignoreCase bool) (*Pattern, error) {
+	if expr == "" {
+		return nil, errors.New("the expression is empty")
+	}
+	flags := syntax.Perl
+	full := expr
+	if ignoreCase {
+		flags |= syntax.FoldCase
+		full = "(?i)" + expr
+	}
+	re, err := regexp.Compile(full)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := syntax.Parse(expr, flags)
+	if err != nil {
+		return nil, err
+	}
+	return &Pattern{
+		Expr:       expr,
+		IgnoreCase: ignoreCase,
+		re:         re,
+		need:       needOf(tree.Simplify()),
+	}, nil
+}
+
+// Regexp is the compiled expression
+func (p *Pattern) Regexp() *regexp.Regexp {
+	return p.re
+}
+
+// Query finds the candidate files in an index with that mapping
+// the words are split like each of analyzers does, the field one if none
+// nil means any file can match and all of them must be scanned
+func (p *Pattern) Query(m mapping.IndexMapping, field string, analyzers []string) query.Query {
+	if len(analyzers) == 0 {
+		analyzers = []string{m.AnalyzerNameForPath(field)}
+	}
+	wa := &wordAnalyzers{partial: true}
+	for _, name := range analyzers {
+		analyzer := m.AnalyzerNamed(name)
+		if analyzer == nil {
+			return nil
+		}
+		wa.analyzers = append(wa.analyzers, analyzer)
+		// stemmers and splitters change the pieces of words
+		wa.partial = wa.partial && name == standard.Name
+	}
+	return p.need.query(field, wa)
+}
+
+// ScanFile calls onMatch for every match in the file in order
+// it stops when ctx is done or onMatch returns false
+func (p *Pattern) ScanFile(ctx context.Context, path string, onMatch func(Match) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return p.Scan(ctx, path, file, onMatch)
+}
+
+// Scan is ScanFile over a reader, path is only reported in the matches
+func (p *Pattern) Scan(ctx context.Context, path string, r io.Reader, onMatch func(Match) bool) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var offset int64
+	for line := 1; ; line++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		data, n, err := readLine(reader)
+		if n > 0 || err == nil {
+			text := trimEOL(data)
+			for _, loc := range p.re.FindAllIndex(text, -1) {
+				m := Match{
+					Path:   path,
+					Line:   line,
+					Column: loc[0] + 1,
+					Offset: offset + int64(loc[0]),
+					Length: loc[1] - loc[0],
+					Text:   string(text),
+				}
+				if !onMatch(m) {
+					return nil
+				}
+			}
+		}
+		offset += int64(n)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readLine reads up to and with the next \n, n is its length
+// the bytes after maxLine are skipped but counted
+func readLine(r *bufio.Reader) (line []byte, n int, err error) {
+	for {
+		chunk, err := r.ReadSlice('\n')
+		n += len(chunk)
+		if len(line) < maxLine {
+			line = append(line, chunk[:min(len(chunk), maxLine-len(line))]...)
+		}
+		if err != bufio.ErrBufferFull {
+			return line, n, err
+		}
+	}
+}
+
+func trimEOL(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == '\n' {
+		b = b[:n-1]
+		if n := len(b); n > 0 && b[n-1] == '\r' {
+			b = b[:n-1]
+		}
+	}
+	return b
+}
@@ -0,0 +1,246 @@
+package grep
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// need is what a file must contain to match the regex
+// a tree of words joined by AND and OR, nil needs nothing
+type need struct {
+	and  bool // all subs, or any of them
+	subs []*need
+	word fragment
+}
+
+// fragment is a piece of a word found in a literal, case kept
+// for the analyzers splitting camelCase, a known edge is a word boundary, else the word can go on
+type fragment struct {
+	text       string
+	startKnown bool
+	endKnown   bool
+}
+
+func needAll(subs []*need) *need {
+	var kept []*need
+	for _, s := range subs {
+		if s != nil {
+			kept = append(kept, s)
+		}
+	}
+	switch len(kept) {
+	case 0:
+		return nil
+	case 1:
+		return kept[0]
+	}
+	return &need{and: true, subs: kept}
+}
+
+// needAny is nil when one branch needs nothing
+func needAny(subs []*need) *need {
+	for _, s := range subs {
+		if s == nil {
+			return nil
+		}
+	}
+	if len(subs) == 1 {
+		return subs[0]
+	}
+	return &need{subs: subs}
+}
+
+// needOf walks the regex to find the literals every match contains
+func needOf(re *syntax.Regexp) *need {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return needOfLiteral(string(re.Rune), false, false)
+	case syntax.OpCapture:
+		return needOf(re.Sub[0])
+	case syntax.OpPlus:
+		return needOf(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return needOf(re.Sub[0])
+		}
+	case syntax.OpAlternate:
+		subs := make([]*need, 0, len(re.Sub))
+		for _, sub := range re.Sub {
+			subs = append(subs, needOf(sub))
+		}
+		return needAny(subs)
+	case syntax.OpConcat:
+		var subs []*need
+		for i := 0; i < len(re.Sub); i++ {
+			if re.Sub[i].Op != syntax.OpLiteral {
+				subs = append(subs, needOf(re.Sub[i]))
+				continue
+			}
+			// join the literals next to each other
+			var run strings.Builder
+			start := i
+			for ; i < len(re.Sub) && re.Sub[i].Op == syntax.OpLiteral; i++ {
+				run.WriteString(string(re.Sub[i].Rune))
+			}
+			startKnown := start > 0 && isBoundary(re.Sub[start-1])
+			endKnown := i < len(re.Sub) && isBoundary(re.Sub[i])
+			subs = append(subs, needOfLiteral(run.String(), startKnown, endKnown))
+			i--
+		}
+		return needAll(subs)
+	}
+	return nil
+}
+
+func isBoundary(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpWordBoundary, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return true
+	}
+	return false
+}
+
+// needOfLiteral splits a literal in the words the index holds
+// spaces and most punctuation end words for sure,
+// the tokenizer keeps words like e.mail or can't in one piece
+func needOfLiteral(s string, startKnown, endKnown bool) *need {
+	var subs []*need
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		if !isWord(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWord(runes[j]) {
+			j++
+		}
+		f := fragment{
+			text:       string(runes[i:j]),
+			startKnown: (i == 0 && startKnown) || (i > 0 && isBreak(runes[i-1])),
+			endKnown:   (j == len(runes) && endKnown) || (j < len(runes) && isBreak(runes[j])),
+		}
+		subs = append(subs, &need{word: f})
+		i = j
+	}
+	return needAll(subs)
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// isBreak reports if r can not be inside a token
+func isBreak(r rune) bool {
+	return !isWord(r) && !strings.ContainsRune(".,;:'’", r)
+}
+
+// minFragment is the shortest piece of a word worth a wildcard
+const minFragment = 3
+
+var stopWords = loadStopWords()
+
+func loadStopWords() []string {
+	tokens := analysis.NewTokenMap()
+	if err := tokens.LoadBytes(en.EnglishStopWords); err != nil {
+		return nil
+	}
+	words := make([]string, 0, len(tokens))
+	for w := range tokens {
+		words = append(words, w)
+	}
+	return words
+}
+
+// partOfStopWord reports if the fragment could be a word never indexed
+func partOfStopWord(s string) bool {
+	for _, w := range stopWords {
+		if strings.Contains(w, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordAnalyzers split whole words like the index did
+type wordAnalyzers struct {
+	analyzers []analysis.Analyzer
+	// pieces of words can be looked up, no analyzer changes them
+	partial bool
+}
+
+// query turns the need in a query over field
+// it returns nil when every document is a candidate
+func (n *need) query(field string, wa *wordAnalyzers) query.Query {
+	if n == nil {
+		return nil
+	}
+	if n.subs == nil {
+		return n.word.query(field, wa)
+	}
+	var queries []query.Query
+	for _, sub := range n.subs {
+		q := sub.query(field, wa)
+		if q == nil {
+			if !n.and {
+				return nil
+			}
+			continue
+		}
+		queries = append(queries, q)
+	}
+	return join(queries, n.and)
+}
+
+func join(queries []query.Query, and bool) query.Query {
+	switch {
+	case len(queries) == 0:
+		return nil
+	case len(queries) == 1:
+		return queries[0]
+	case and:
+		return query.NewConjunctionQuery(queries)
+	}
+	return query.NewDisjunctionQuery(queries)
+}
+
+func (f fragment) query(field string, wa *wordAnalyzers) query.Query {
+	if f.startKnown && f.endKnown {
+		// a document was indexed by one of the analyzers
+		var alternatives []query.Query
+		for _, analyzer := range wa.analyzers {
+			var terms []query.Query
+			for _, tok := range analyzer.Analyze([]byte(f.text)) {
+				q := query.NewTermQuery(string(tok.Term))
+				q.SetField(field)
+				terms = append(terms, q)
+			}
+			if len(terms) == 0 {
+				return nil // a stop word is not indexed
+			}
+			alternatives = append(alternatives, join(terms, true))
+		}
+		return join(alternatives, false)
+	}
+	text := strings.ToLower(f.text)
+	if !wa.partial || len(text) < minFragment || partOfStopWord(text) {
+		return nil
+	}
+	if f.startKnown {
+		q := query.NewPrefixQuery(text)
+		q.SetField(field)
+		return q
+	}
+	pattern := "*" + text
+	if !f.endKnown {
+		pattern += "*"
+	}
+	q := query.NewWildcardQuery(pattern)
+	q.SetField(field)
+	return q
+}
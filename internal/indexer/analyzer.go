@@ -0,0 +1,124 @@
+package indexer
+
+import (
+	"GoSeek/internal/models"
+	"maps"
+	"slices"
+
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/analysis/token/camelcase"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/regexp"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+	"github.com/blevesearch/bleve/v2/mapping"
+)
+
+// FilenameAnalyzer splits file names and paths in words
+// on separators, dots, "_", "-" and camelCase so
+// "invoiceReport_2023.txt" gives invoice, report, 2023 and txt
+const FilenameAnalyzer = "filename"
+
+const filenameTokenizer = "filename_words"
+
+// CodeAnalyzer splits source code identifiers in words
+// getUserName and get_user_name both give get, user and name
+const CodeAnalyzer = "code"
+
+// SymbolAnalyzer keeps a symbol name whole, only lower cased
+// so symbol:newcoordinator finds NewCoordinator
+const SymbolAnalyzer = "symbol"
+
+// StandardAnalyzer is the content analyzer of indexes without a choice
+const StandardAnalyzer = standard.Name
+
+// Analyzers are the content analyzers an index can use
+// besides standard and code, a language name analyzes with its
+// stemming and stop words
+var Analyzers = append([]string{StandardAnalyzer, CodeAnalyzer}, Languages...)
+
+// ValidAnalyzer reports if name is one of Analyzers
+func ValidAnalyzer(name string) bool {
+	return slices.Contains(Analyzers, name)
+}
+
+// addAnalyzers registers the custom analyzers of the mapping
+func addAnalyzers(m *mapping.IndexMappingImpl) error {
+	err := m.AddCustomTokenizer(filenameTokenizer, map[string]interface{}{
+		"type":   regexp.Name,
+		"regexp": `[\p{L}\p{N}]+`,
+	})
+	if err != nil {
+		return err
+	}
+	// the code analyzer gives the same words as the file names,
+	// "_" splits like the other separators
+	for _, name := range []string{FilenameAnalyzer, CodeAnalyzer} {
+		err = m.AddCustomAnalyzer(name, map[string]interface{}{
+			"type":          custom.Name,
+			"tokenizer":     filenameTokenizer,
+			"token_filters": []string{camelcase.Name, lowercase.Name},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return m.AddCustomAnalyzer(SymbolAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+}
+
+// DefaultAnalyzer analyzes the content of the documents without another one
+func (o IndexOptions) DefaultAnalyzer() string {
+	if o.Analyzer == "" {
+		return StandardAnalyzer
+	}
+	return o.Analyzer
+}
+
+// ContentWordsField holds the content of an index with other analyzers
+// than the standard one, split by the standard analyzer: its dictionary
+// has words and not stems, and pieces of words can be looked up in it
+const ContentWordsField = "content_words"
+
+// HasContentWords reports if the index has a ContentWordsField
+func (o IndexOptions) HasContentWords() bool {
+	return slices.ContainsFunc(o.ContentAnalyzers(), func(a string) bool {
+		return a != StandardAnalyzer
+	})
+}
+
+// Stemmed reports if some content is indexed by a language analyzer
+// its dictionary then holds stems and not words
+func (o IndexOptions) Stemmed() bool {
+	return slices.ContainsFunc(o.ContentAnalyzers(), func(a string) bool {
+		return slices.Contains(Languages, a)
+	})
+}
+
+// ContentAnalyzers lists every analyzer the content can be indexed with
+// queries are analyzed with each of them
+func (o IndexOptions) ContentAnalyzers() []string {
+	others := slices.Collect(maps.Values(o.ExtAnalyzers))
+	if o.DetectLanguage {
+		others = append(others, Languages...)
+	}
+	others = slices.DeleteFunc(others, func(a string) bool { return a == o.DefaultAnalyzer() })
+	slices.Sort(others)
+	return append([]string{o.DefaultAnalyzer()}, slices.Compact(others)...)
+}
+
+// analyzerFor picks the analyzer of a document
+// the default one is returned as "" so it gets the default mapping
+func (o IndexOptions) analyzerFor(doc *models.Document) string {
+	analyzer, ok := o.ExtAnalyzers[doc.Extension]
+	if !ok && o.DetectLanguage {
+		analyzer = DetectLanguage(doc.Content)
+	}
+	if analyzer == o.DefaultAnalyzer() {
+		return ""
+	}
+	return analyzer
+}
@@ -3,6 +3,8 @@ package indexer
 import (
 	// "GoSeek/config"
 
+	"GoSeek/internal/embed"
+	"GoSeek/internal/locate"
 	"GoSeek/internal/models"
 	"encoding/json"
 	"fmt"
@@ -13,14 +15,41 @@ import (
 	"sync"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
 )
 
 type BleveIndexer struct {
 	Index     bleve.Index
+	Options   IndexOptions
+	Names     *locate.Index // every path of the folder, indexed or not
 	stats     IndexStats
 	statsLock sync.Mutex
+
+	embedder embed.Embedder // nil without embeddings
+	vectors  *vectorStore
+}
+
+// IndexOptions are chosen when the index is created
+// and kept in its internal key space
+type IndexOptions struct {
+	// StoreContent keeps the content so search results
+	// can show highlighted snippets (bigger index)
+	StoreContent bool `json:"store_content"`
+	// Analyzer splits the content in words, standard if empty
+	Analyzer string `json:"analyzer,omitempty"`
+	// ExtAnalyzers overrides Analyzer by extension, like ".go": "code"
+	ExtAnalyzers map[string]string `json:"ext_analyzers,omitempty"`
+	// DetectLanguage analyzes the other documents with the
+	// analyzer of their language when it can be told
+	DetectLanguage bool `json:"detect_language,omitempty"`
+	// Embedder computes the embeddings of the chunks of the content
+	// for the similar meaning search, like "hashing", none if empty
+	Embedder string `json:"embedder,omitempty"`
 }
 
+const optionsKey = "__options__"
+
 // NewBleveIndexer creates a new BleveIndexer in specific path
 // with default configurations as all fields are not indexed
 // except the content one + It uses scorch engine as backend
@@ -30,7 +59,7 @@ type BleveIndexer struct {
 // Make configurations more customized according to user choices
 // Handle indexPath operations and Cases (already found index in this path , Rename by user op , etc..)
 
-func NewBleveIndexer(folderPath string, extensions map[string]bool) (*BleveIndexer, error) {
+func NewBleveIndexer(folderPath string, extensions map[string]bool, opts IndexOptions) (*BleveIndexer, error) {
 
 	// IF IT IS FOUND RETURN IT
 	indexpath := "index/" + filepath.Base(folderPath)
@@ -40,13 +69,83 @@ func NewBleveIndexer(folderPath string, extensions map[string]bool) (*BleveIndex
 		return nil, fmt.Errorf("there is already index with that path")
 	}
 
+	embedder, err := newEmbedder(opts)
+	if err != nil {
+		return nil, err
+	}
 	indexMapping := bleve.NewIndexMapping()
+	if err := addAnalyzers(indexMapping); err != nil {
+		return nil, err
+	}
+
+	indexMapping.DefaultMapping = newDocumentMapping(opts, opts.DefaultAnalyzer())
+	// documents analyzed differently are their own type
+	for _, analyzer := range opts.ContentAnalyzers() {
+		if analyzer != opts.DefaultAnalyzer() {
+			indexMapping.AddDocumentMapping(analyzer, newDocumentMapping(opts, analyzer))
+		}
+	}
+	if embedder != nil {
+		addVectorFields(indexMapping, embedder.Dims())
+	}
+
+	index, err := bleve.NewUsing(indexpath, indexMapping, bleve.Config.DefaultIndexType, "scorch", nil)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(extensions)
+
+	err = index.SetInternal([]byte("__extensions__"), data)
+	if err != nil {
+		return nil, err
+	}
+	err = index.SetInternal([]byte("__base_path__"), []byte(filepath.Dir(folderPath)))
+	if err != nil {
+		return nil, err
+	}
+	data, _ = json.Marshal(opts)
+	err = index.SetInternal([]byte(optionsKey), data)
+	if err != nil {
+		return nil, err
+	}
+	bi := &BleveIndexer{
+		Index:    index,
+		Options:  opts,
+		Names:    locate.New(),
+		stats:    IndexStats{},
+		embedder: embedder,
+		vectors:  newVectorStore(),
+	}
+	if err := bi.saveSchemaVersion(); err != nil {
+		return nil, err
+	}
+	return bi, nil
+}
 
+// newDocumentMapping maps the fields of a document
+// with its content split by analyzer
+func newDocumentMapping(opts IndexOptions, analyzer string) *mapping.DocumentMapping {
 	// Fields
 	contentField := bleve.NewTextFieldMapping()
 	contentField.Index = true
-	contentField.Store = false
-	contentField.IncludeTermVectors = false
+	contentField.Store = opts.StoreContent
+	// positions are needed by phrase and NEAR queries
+	contentField.IncludeTermVectors = true
+	if analyzer != StandardAnalyzer {
+		contentField.Analyzer = analyzer
+	}
+	contentFields := []*mapping.FieldMapping{contentField}
+	// stemmed or split content keeps its words too, the completions,
+	// corrections and grep candidates are read from them, see ContentWordsField
+	if opts.HasContentWords() {
+		wordsField := bleve.NewTextFieldMapping()
+		wordsField.Name = ContentWordsField
+		wordsField.Analyzer = StandardAnalyzer
+		wordsField.Store = false
+		wordsField.IncludeTermVectors = false
+		wordsField.IncludeInAll = false
+		contentFields = append(contentFields, wordsField)
+	}
 
 	dirFiled := bleve.NewTextFieldMapping()
 	dirFiled.Index = true
@@ -73,39 +172,117 @@ func NewBleveIndexer(folderPath string, extensions map[string]bool) (*BleveIndex
 	extensionField.IncludeTermVectors = false
 	extensionField.IncludeInAll = false
 
+	// file name as one term, used to sort the results
+	nameField := bleve.NewKeywordFieldMapping()
+	nameField.Store = true
+	nameField.IncludeInAll = false
+
+	// file name and relative path split in words
+	filenameField := bleve.NewTextFieldMapping()
+	filenameField.Name = "filename"
+	filenameField.Analyzer = FilenameAnalyzer
+	filenameField.Store = false
+	filenameField.IncludeInAll = false
+
+	pathField := bleve.NewTextFieldMapping()
+	pathField.Analyzer = FilenameAnalyzer
+	pathField.Store = false
+	pathField.IncludeInAll = false
+
+	// definitions of source files, whole names
+	symbolField := bleve.NewTextFieldMapping()
+	symbolField.Analyzer = SymbolAnalyzer
+	symbolField.Store = false
+	symbolField.IncludeInAll = false
+
+	// comments and string literals are words like the content
+	commentField := bleve.NewTextFieldMapping()
+	commentField.Store = false
+	commentField.IncludeTermVectors = true
+	commentField.IncludeInAll = false
+	commentField.Analyzer = contentField.Analyzer
+	stringField := bleve.NewTextFieldMapping()
+	stringField.Store = false
+	stringField.IncludeTermVectors = true
+	stringField.IncludeInAll = false
+	stringField.Analyzer = contentField.Analyzer
+
+	// hashes of the content telling the copies, see dedup
+	hashField := bleve.NewKeywordFieldMapping()
+	hashField.Store = true
+	hashField.IncludeInAll = false
+	simHashField := bleve.NewKeywordFieldMapping()
+	simHashField.Store = true
+	simHashField.IncludeInAll = false
+
+	// metadata of the file, see package metadata
+	// the words of the author are searched, the whole name is counted
+	authorField := newMetaTextField()
+	authorFacetField := bleve.NewKeywordFieldMapping()
+	authorFacetField.Name = "author_exact"
+	authorFacetField.Store = false
+	authorFacetField.IncludeInAll = false
+
+	createdField := bleve.NewDateTimeFieldMapping()
+	createdField.Store = true
+	createdField.IncludeInAll = false
+
+	pagesField := bleve.NewNumericFieldMapping()
+	pagesField.Store = true
+	pagesField.IncludeInAll = false
+
+	// lower cased so language:Python finds python
+	languageField := bleve.NewTextFieldMapping()
+	languageField.Analyzer = SymbolAnalyzer
+	languageField.Store = true
+	languageField.IncludeInAll = false
+
+	ownerField := bleve.NewKeywordFieldMapping()
+	ownerField.Store = true
+	ownerField.IncludeInAll = false
+	permField := bleve.NewKeywordFieldMapping()
+	permField.Store = true
+	permField.IncludeInAll = false
+
 	documentMapping := bleve.NewDocumentMapping()
+	documentMapping.AddFieldMappingsAt("name", nameField, filenameField)
+	documentMapping.AddFieldMappingsAt("path", pathField)
 	documentMapping.AddFieldMappingsAt("dir", dirFiled)
-	documentMapping.AddFieldMappingsAt("content", contentField)
+	documentMapping.AddFieldMappingsAt("content", contentFields...)
 	documentMapping.AddFieldMappingsAt("size", sizeField)
 	documentMapping.AddFieldMappingsAt("mod_time", modTimeField)
 	documentMapping.AddFieldMappingsAt("extension", extensionField)
+	documentMapping.AddFieldMappingsAt("symbol", symbolField)
+	documentMapping.AddFieldMappingsAt("comment", commentField)
+	documentMapping.AddFieldMappingsAt("string", stringField)
+	documentMapping.AddFieldMappingsAt("hash", hashField)
+	documentMapping.AddFieldMappingsAt("simhash", simHashField)
+	documentMapping.AddFieldMappingsAt("author", authorField, authorFacetField)
+	documentMapping.AddFieldMappingsAt("title", newMetaTextField())
+	documentMapping.AddFieldMappingsAt("created", createdField)
+	documentMapping.AddFieldMappingsAt("pages", pagesField)
+	documentMapping.AddFieldMappingsAt("camera", newMetaTextField())
+	documentMapping.AddFieldMappingsAt("keywords", newMetaTextField())
+	documentMapping.AddFieldMappingsAt("language", languageField)
+	documentMapping.AddFieldMappingsAt("owner", ownerField)
+	documentMapping.AddFieldMappingsAt("perm", permField)
+	return documentMapping
+}
 
-	indexMapping.DefaultMapping = documentMapping
-
-	index, err := bleve.NewUsing(indexpath, indexMapping, bleve.Config.DefaultIndexType, "scorch", nil)
-	if err != nil {
-		return nil, err
-	}
-	data, _ := json.Marshal(extensions)
-
-	err = index.SetInternal([]byte("__extensions__"), data)
-	if err != nil {
-		return nil, err
-	}
-	err = index.SetInternal([]byte("__base_path__"), []byte(filepath.Dir(folderPath)))
-	if err != nil {
-		return nil, err
-	}
-	return &BleveIndexer{
-		Index: index,
-		stats: IndexStats{},
-	}, nil
+// newMetaTextField maps a metadata text searched by its words
+// positions are kept for phrases like title:"Q3 plan"
+func newMetaTextField() *mapping.FieldMapping {
+	field := bleve.NewTextFieldMapping()
+	field.Store = true
+	field.IncludeTermVectors = true
+	field.IncludeInAll = false
+	return field
 }
 
 func OpenBleve(indexpath string) *BleveIndexer {
+	// no index there yet
 	_, err := os.Stat(indexpath)
 	if err != nil {
-		println(err)
 		return nil
 	}
 	var index bleve.Index
@@ -113,14 +290,39 @@ func OpenBleve(indexpath string) *BleveIndexer {
 	if err != nil {
 		return nil
 	}
-	return &BleveIndexer{
-		Index: index,
-		stats: IndexStats{},
+	// indexes created before the options have none set
+	var opts IndexOptions
+	if data, err := index.GetInternal([]byte(optionsKey)); err == nil && data != nil {
+		json.Unmarshal(data, &opts)
 	}
+	names, err := loadNames(index)
+	if err != nil {
+		fmt.Printf("Error loading file names of %s: %v\n", indexpath, err)
+		names = locate.New()
+	}
+	bi := &BleveIndexer{
+		Index:   index,
+		Options: opts,
+		Names:   names,
+		stats:   IndexStats{},
+		vectors: newVectorStore(),
+	}
+	// without its embedder the index is still searched by words
+	if bi.embedder, err = newEmbedder(opts); err != nil {
+		fmt.Printf("Error loading the embedder of %s: %v\n", indexpath, err)
+	}
+	if err := bi.loadVectors(); err != nil {
+		fmt.Printf("Error loading the embeddings of %s: %v\n", indexpath, err)
+	}
+	return bi
 }
 
+// BatchIndex commits the batch, the embeddings in memory
+// only change with the index
 func (bi *BleveIndexer) BatchIndex(batch *bleve.Batch) error {
-	return bi.Index.Batch(batch)
+	err := bi.Index.Batch(batch)
+	bi.commitVectors(batch, err == nil)
+	return err
 }
 
 // NewBatch - Create new batch
@@ -130,23 +332,31 @@ func (bi *BleveIndexer) NewBatch() *bleve.Batch {
 
 // IndexDocument - Index single document to batch
 func (bi *BleveIndexer) IndexDocument(batch *bleve.Batch, doc *models.Document) error {
+	doc.Analyzer = bi.Options.analyzerFor(doc)
+	bi.storeVectors(batch, doc)
 	return batch.Index(doc.Path, doc)
 }
 
 // DeleteDocument - Delete document from index
 func (bi *BleveIndexer) DeleteDocumentBatch(batch *bleve.Batch, filePath string) {
 	batch.Delete(filePath)
+	bi.deleteVectors(batch, filePath)
 }
 
 func (bi *BleveIndexer) DeleteSingleDocument(filePath string) {
-	bi.Index.Delete(filePath)
+	// the embeddings of a document still indexed are kept
+	if err := bi.Index.Delete(filePath); err != nil {
+		fmt.Printf("Error deleting %s: %v\n", filePath, err)
+		return
+	}
+	bi.deleteVectors(nil, filePath)
 }
 
-func (bi *BleveIndexer) FlushBatch(batch *bleve.Batch, batchSize, batchCount *int32) {
-	start := time.Now()
-	// println("-----> batchSize:", *batchSize/(1024*1024))
+// FlushBatch commits the batch to the index and resets its counters
+func (bi *BleveIndexer) FlushBatch(batch *bleve.Batch, batchSize, batchCount *int32) error {
+	var err error
 	if batch.Size() > 0 {
-		if err := bi.BatchIndex(batch); err != nil {
+		if err = bi.BatchIndex(batch); err != nil {
 			fmt.Printf("Error indexing batch: %v\n", err)
 		} else {
 			bi.UpdateStats(*batchSize, *batchCount)
@@ -155,47 +365,118 @@ func (bi *BleveIndexer) FlushBatch(batch *bleve.Batch, batchSize, batchCount *in
 		*batchSize = 0
 		*batchCount = 0
 	}
-	end := time.Now()
-	println("---------->", end.Sub(start).String()) // Time for every batch
+	return err
+}
+
+// SearchHits are the documents found in one index
+type SearchHits struct {
+	Docs   []models.Document
+	Total  uint64              // number of matching documents, can be more than Docs
+	Facets search.FacetResults // counts of the facets asked by the request
 }
 
 // Search return the results found in index according to the query
+// Fragments are filled when the request asks for highlighting
 
-func (bi *BleveIndexer) Search(req *bleve.SearchRequest) ([]models.Document, error) {
-	basepath, err := bi.Index.GetInternal([]byte("__base_path__"))
+func (bi *BleveIndexer) Search(req *bleve.SearchRequest) (*SearchHits, error) {
+	basePath, err := bi.BasePath()
 	if err != nil {
 		return nil, err
 	}
-	basePath := string(basepath)
 	SearchResult, err := bi.Index.Search(req)
-	if SearchResult == nil {
-		println(err.Error())
-		return nil, nil
+	if err != nil {
+		return nil, err
 	}
 	var results []models.Document
 	for _, hit := range SearchResult.Hits {
-		if hit.Fields == nil {
-			return nil, nil
-		}
 		// fmt.Println(hit.ID)
 		doc := models.Document{
 			Path:      basePath + string(filepath.Separator) + hit.ID,
+			Name:      stringField(hit.Fields, "name"),
 			Score:     hit.Score,
-			Size:      int64(hit.Fields["size"].(float64)),
-			ModTime:   hit.Fields["mod_time"].(string),
-			Extension: hit.Fields["extension"].(string),
+			Size:      int64(floatField(hit.Fields, "size")),
+			ModTime:   timeField(hit.Fields, "mod_time"),
+			Extension: stringField(hit.Fields, "extension"),
+			Hash:      stringField(hit.Fields, "hash"),
+			SimHash:   stringField(hit.Fields, "simhash"),
+			Author:    stringField(hit.Fields, "author"),
+			Title:     stringField(hit.Fields, "title"),
+			Camera:    stringField(hit.Fields, "camera"),
+			Language:  stringField(hit.Fields, "language"),
+			Owner:     stringField(hit.Fields, "owner"),
+			Perm:      stringField(hit.Fields, "perm"),
 			// Dir:       hit.Fields["dir"].(string),
 			// Content: hit.Fields["Content"].(string),
 		}
-		// if snippets, ok := hit.Fragments["content"]; ok {
-		// 	for _, snippet := range snippets {
-		// 		fmt.Println("Snippet:", snippet)
-		// 	}
-		// }
+		if doc.Name == "" {
+			doc.Name = filepath.Base(hit.ID)
+		}
+		if created := timeField(hit.Fields, "created"); !created.IsZero() {
+			doc.Created = &created
+		}
+		if pages := int(floatField(hit.Fields, "pages")); pages > 0 {
+			doc.Pages = &pages
+		}
+		doc.Keywords = stringsField(hit.Fields, "keywords")
+		if snippets, ok := hit.Fragments["content"]; ok {
+			doc.Fragments = snippets
+		}
 		// println(doc.Path, doc.Size, doc.Extension, doc.ModTime)
 		results = append(results, doc)
 	}
-	return results, nil
+	return &SearchHits{
+		Docs:   results,
+		Total:  SearchResult.Total,
+		Facets: SearchResult.Facets,
+	}, nil
+}
+
+// BasePath returns the parent of the indexed folder
+// document ids are relative to it
+func (bi *BleveIndexer) BasePath() (string, error) {
+	basePath, err := bi.Index.GetInternal([]byte("__base_path__"))
+	if err != nil {
+		return "", err
+	}
+	return string(basePath), nil
+}
+
+// CanHighlight reports if the index stores what the highlighter needs
+func (bi *BleveIndexer) CanHighlight() bool {
+	return bi.Options.StoreContent
+}
+
+func stringField(fields map[string]interface{}, name string) string {
+	v, _ := fields[name].(string)
+	return v
+}
+
+// stringsField reads a stored text field of one or more values
+func stringsField(fields map[string]interface{}, name string) []string {
+	switch v := fields[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, value := range v {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// timeField reads a stored datetime, bleve returns them as RFC3339
+func timeField(fields map[string]interface{}, name string) time.Time {
+	t, _ := time.Parse(time.RFC3339, stringField(fields, name))
+	return t
+}
+
+func floatField(fields map[string]interface{}, name string) float64 {
+	v, _ := fields[name].(float64)
+	return v
 }
 
 // Close the index and release the resources
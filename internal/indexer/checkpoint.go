@@ -0,0 +1,97 @@
+package indexer
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/blevesearch/bleve/v2/numeric"
+)
+
+const checkpointKey = "__scan_checkpoint__"
+
+const (
+	ScanRunning   = "running"
+	ScanDone      = "done"
+	ScanCancelled = "cancelled"
+)
+
+// ScanCheckpoint records how far the initial scan of an index went
+// it is kept in the internal key space of the index
+// so it is committed with the documents it describes
+type ScanCheckpoint struct {
+	Root      string    `json:"root"`
+	State     string    `json:"state"`
+	Committed int64     `json:"committed"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Interrupted reports if the scan stopped without finishing or being cancelled
+func (cp *ScanCheckpoint) Interrupted() bool {
+	return cp != nil && cp.State == ScanRunning
+}
+
+func (bi *BleveIndexer) SaveCheckpoint(cp *ScanCheckpoint) error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return bi.Index.SetInternal([]byte(checkpointKey), data)
+}
+
+// LoadCheckpoint returns the last saved checkpoint or nil if there is none
+func (bi *BleveIndexer) LoadCheckpoint() (*ScanCheckpoint, error) {
+	data, err := bi.Index.GetInternal([]byte(checkpointKey))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	cp := &ScanCheckpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Unchanged reports if the document of id is committed with the size
+// and modification time of info, they are read from the doc values
+// so the stored document and its content are never loaded
+func (bi *BleveIndexer) Unchanged(id string, info os.FileInfo) bool {
+	idx, err := bi.Index.Advanced()
+	if err != nil {
+		return false
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		return false
+	}
+	defer reader.Close()
+	internal, err := reader.InternalID(id)
+	if err != nil || internal == nil {
+		return false
+	}
+	values, err := reader.DocValueReader([]string{"size", "mod_time"})
+	if err != nil {
+		return false
+	}
+	sizeOK, timeOK := false, false
+	err = values.VisitDocValues(internal, func(field string, term []byte) {
+		// only the full precision term holds the value
+		coded := numeric.PrefixCoded(term)
+		if shift, err := coded.Shift(); err != nil || shift != 0 {
+			return
+		}
+		v, err := coded.Int64()
+		if err != nil {
+			return
+		}
+		switch field {
+		case "size":
+			sizeOK = numeric.Int64ToFloat64(v) == float64(info.Size())
+		case "mod_time":
+			timeOK = v == info.ModTime().UnixNano()
+		}
+	})
+	return err == nil && sizeOK && timeOK
+}
@@ -0,0 +1,127 @@
+package indexer
+
+import (
+	"fmt"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// DictTerm is a term of a field dictionary
+type DictTerm struct {
+	Term     string
+	Count    uint64 // documents having it
+	Distance int    // edits from the term looked for, fuzzy lookups only
+}
+
+// maxDictScan bounds the terms read by a prefix lookup
+// so short prefixes of big indexes stay fast
+const maxDictScan = 20000
+
+// PrefixTerms are the terms of the field starting with prefix
+func (bi *BleveIndexer) PrefixTerms(field, prefix string) ([]DictTerm, error) {
+	dict, err := bi.Index.FieldDictPrefix(field, []byte(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer dict.Close()
+	var terms []DictTerm
+	for len(terms) < maxDictScan {
+		entry, err := dict.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			break
+		}
+		terms = append(terms, DictTerm{Term: entry.Term, Count: entry.Count})
+	}
+	return terms, nil
+}
+
+// FieldTerms are all the terms of the field
+func (bi *BleveIndexer) FieldTerms(field string) ([]DictTerm, error) {
+	dict, err := bi.Index.FieldDict(field)
+	if err != nil {
+		return nil, err
+	}
+	defer dict.Close()
+	var terms []DictTerm
+	for {
+		entry, err := dict.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return terms, nil
+		}
+		terms = append(terms, DictTerm{Term: entry.Term, Count: entry.Count})
+	}
+}
+
+// FuzzyTerms are the terms of the field at most edits away from term
+func (bi *BleveIndexer) FuzzyTerms(field, term string, edits int) ([]DictTerm, error) {
+	idx, err := bi.Index.Advanced()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	fuzzy, ok := reader.(index.IndexReaderFuzzy)
+	if !ok {
+		return nil, fmt.Errorf("the index can not look up close terms")
+	}
+	dict, automaton, err := fuzzy.FieldDictFuzzyAutomaton(field, term, edits, "")
+	if err != nil {
+		return nil, err
+	}
+	defer dict.Close()
+	var terms []DictTerm
+	for {
+		entry, err := dict.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return terms, nil
+		}
+		if ok, distance := automaton.MatchAndDistance(entry.Term); ok {
+			terms = append(terms, DictTerm{Term: entry.Term, Count: entry.Count, Distance: int(distance)})
+		}
+	}
+}
+
+// HasWord reports if the word is indexed in the field
+// it is analyzed like the field, by every content analyzer for the content
+// a word left without terms, like a stop word, is indexed
+func (bi *BleveIndexer) HasWord(field, word string) (bool, error) {
+	analyzers := []string{FilenameAnalyzer}
+	if field != "filename" {
+		analyzers = bi.Options.ContentAnalyzers()
+	}
+	empty := true
+	for _, name := range analyzers {
+		analyzer := bi.Index.Mapping().AnalyzerNamed(name)
+		if analyzer == nil {
+			continue
+		}
+		for _, tok := range analyzer.Analyze([]byte(word)) {
+			empty = false
+			dict, err := bi.Index.FieldDictRange(field, tok.Term, tok.Term)
+			if err != nil {
+				return false, err
+			}
+			entry, err := dict.Next()
+			dict.Close()
+			if err != nil {
+				return false, err
+			}
+			if entry != nil {
+				return true, nil
+			}
+		}
+	}
+	return empty, nil
+}
@@ -0,0 +1,107 @@
+package indexer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ar"
+	"github.com/blevesearch/bleve/v2/analysis/lang/da"
+	"github.com/blevesearch/bleve/v2/analysis/lang/de"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/analysis/lang/es"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fi"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	"github.com/blevesearch/bleve/v2/analysis/lang/hu"
+	"github.com/blevesearch/bleve/v2/analysis/lang/it"
+	"github.com/blevesearch/bleve/v2/analysis/lang/nl"
+	"github.com/blevesearch/bleve/v2/analysis/lang/no"
+	"github.com/blevesearch/bleve/v2/analysis/lang/pt"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ro"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ru"
+	"github.com/blevesearch/bleve/v2/analysis/lang/sv"
+	"github.com/blevesearch/bleve/v2/analysis/lang/tr"
+)
+
+// Languages have an analyzer of the same name and can be detected
+var Languages = []string{
+	ar.AnalyzerName, da.AnalyzerName, de.AnalyzerName, en.AnalyzerName,
+	es.AnalyzerName, fi.AnalyzerName, fr.AnalyzerName, hu.AnalyzerName,
+	it.AnalyzerName, nl.AnalyzerName, no.AnalyzerName, pt.AnalyzerName,
+	ro.AnalyzerName, ru.AnalyzerName, sv.AnalyzerName, tr.AnalyzerName,
+}
+
+var stopWordLists = map[string][]byte{
+	ar.AnalyzerName: ar.ArabicStopWords,
+	da.AnalyzerName: da.DanishStopWords,
+	de.AnalyzerName: de.GermanStopWords,
+	en.AnalyzerName: en.EnglishStopWords,
+	es.AnalyzerName: es.SpanishStopWords,
+	fi.AnalyzerName: fi.FinnishStopWords,
+	fr.AnalyzerName: fr.FrenchStopWords,
+	hu.AnalyzerName: hu.HungarianStopWords,
+	it.AnalyzerName: it.ItalianStopWords,
+	nl.AnalyzerName: nl.DutchStopWords,
+	no.AnalyzerName: no.NorwegianStopWords,
+	pt.AnalyzerName: pt.PortugueseStopWords,
+	ro.AnalyzerName: ro.RomanianStopWords,
+	ru.AnalyzerName: ru.RussianStopWords,
+	sv.AnalyzerName: sv.SwedishStopWords,
+	tr.AnalyzerName: tr.TurkishStopWords,
+}
+
+// stopWords maps a stop word to the languages using it
+var stopWords = loadStopWords()
+
+func loadStopWords() map[string][]string {
+	words := make(map[string][]string)
+	for lang, list := range stopWordLists {
+		tokens := analysis.NewTokenMap()
+		if err := tokens.LoadBytes(list); err != nil {
+			continue
+		}
+		for w := range tokens {
+			words[w] = append(words[w], lang)
+		}
+	}
+	return words
+}
+
+const (
+	detectSample   = 16 * 1024 // bytes of the content looked at
+	detectMinWords = 20        // shorter texts are not detected
+)
+
+// DetectLanguage guesses the language of text from its stop words
+// it returns "" when the text is too short or no language stands out
+func DetectLanguage(text string) string {
+	if len(text) > detectSample {
+		text = text[:detectSample]
+	}
+	counts := make(map[string]int)
+	words := 0
+	for _, w := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		words++
+		for _, lang := range stopWords[strings.ToLower(w)] {
+			counts[lang]++
+		}
+	}
+	if words < detectMinWords {
+		return ""
+	}
+	best, second := "", 0
+	for _, lang := range Languages {
+		switch n := counts[lang]; {
+		case best == "" || n > counts[best]:
+			second = counts[best]
+			best = lang
+		case n > second:
+			second = n
+		}
+	}
+	// stop words are a good part of any text, shared ones must not decide
+	if counts[best]*10 < words || counts[best] < second*3/2 {
+		return ""
+	}
+	return best
+}
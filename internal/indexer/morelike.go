@@ -0,0 +1,155 @@
+package indexer
+
+import (
+	"GoSeek/internal/governor"
+	"GoSeek/internal/metadata"
+	"GoSeek/internal/models"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// WeightedTerm is a term of the content with its weight in a document
+type WeightedTerm struct {
+	Term   string
+	Weight float64
+}
+
+const (
+	// maxLikeBytes is the most read of a file whose content is not stored
+	maxLikeBytes = 1 << 20
+	// minLikeTermLen is the shortest term worth looking for
+	minLikeTermLen = 3
+	// commonDocs is the index size from which terms in more than
+	// half of the documents say nothing about one
+	commonDocs = 10
+)
+
+// likeContent is the content of the document, the stored one or
+// the start of the file when it was not changed since it was indexed
+func (bi *BleveIndexer) likeContent(id string) (string, error) {
+	if bi.Options.StoreContent {
+		req := bleve.NewSearchRequest(bleve.NewDocIDQuery([]string{id}))
+		req.Fields = []string{"content"}
+		res, err := bi.Index.Search(req)
+		if err != nil {
+			return "", err
+		}
+		if len(res.Hits) == 0 {
+			return "", fmt.Errorf("%s is not indexed", id)
+		}
+		return stringField(res.Hits[0].Fields, "content"), nil
+	}
+	basePath, err := bi.BasePath()
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(filepath.Join(basePath, id))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	// the words of the file would not be the indexed ones
+	if !bi.Unchanged(id, info) {
+		return "", fmt.Errorf("%s changed since it was indexed", id)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxLikeBytes))
+	governor.Default().WaitRead(len(data))
+	return string(data), err
+}
+
+// SignificantTerms are the content terms of the document with the highest
+// TF-IDF, how often it uses them times how rare they are in the index
+// terms no other document has can not find any and are left out
+// the weights are scaled so the best one is 1
+func (bi *BleveIndexer) SignificantTerms(id string, limit int) ([]WeightedTerm, error) {
+	// documents and photos have no words
+	if metadata.Binary(filepath.Ext(id)) {
+		return nil, nil
+	}
+	content, err := bi.likeContent(id)
+	if err != nil {
+		return nil, err
+	}
+	doc := &models.Document{Extension: filepath.Ext(id), Content: content}
+	analyzerName := bi.Options.analyzerFor(doc)
+	if analyzerName == "" {
+		analyzerName = bi.Options.DefaultAnalyzer()
+	}
+	analyzer := bi.Index.Mapping().AnalyzerNamed(analyzerName)
+	if analyzer == nil {
+		return nil, fmt.Errorf("unknown analyzer %q", analyzerName)
+	}
+	counts := make(map[string]int)
+	for _, tok := range analyzer.Analyze([]byte(content)) {
+		term := string(tok.Term)
+		if utf8.RuneCountInString(term) >= minLikeTermLen && !number(term) {
+			counts[term]++
+		}
+	}
+
+	idx, err := bi.Index.Advanced()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	docs, err := reader.DocCount()
+	if err != nil {
+		return nil, err
+	}
+	terms := make([]WeightedTerm, 0, len(counts))
+	for term, tf := range counts {
+		tfr, err := reader.TermFieldReader(context.Background(), []byte(term), "content", false, false, false)
+		if err != nil {
+			return nil, err
+		}
+		df := tfr.Count()
+		tfr.Close()
+		if df <= 1 || (docs >= commonDocs && df*2 > docs) {
+			continue
+		}
+		idf := 1 + math.Log(float64(docs)/float64(df+1))
+		terms = append(terms, WeightedTerm{Term: term, Weight: float64(tf) * idf})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Weight != terms[j].Weight {
+			return terms[i].Weight > terms[j].Weight
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	if len(terms) > limit {
+		terms = terms[:limit]
+	}
+	if len(terms) > 0 {
+		best := terms[0].Weight
+		for i := range terms {
+			terms[i].Weight /= best
+		}
+	}
+	return terms, nil
+}
+
+func number(term string) bool {
+	for _, r := range term {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package indexer
+
+import (
+	"GoSeek/internal/locate"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+const namesKey = "__names__"
+
+func loadNames(index bleve.Index) (*locate.Index, error) {
+	data, err := index.GetInternal([]byte(namesKey))
+	if err != nil {
+		return nil, err
+	}
+	return locate.Unmarshal(data)
+}
+
+// SaveNames keeps the file names in the internal store if they changed
+func (bi *BleveIndexer) SaveNames() error {
+	if !bi.Names.Changed() {
+		return nil
+	}
+	data, err := bi.Names.Marshal()
+	if err != nil {
+		return err
+	}
+	return bi.Index.SetInternal([]byte(namesKey), data)
+}
@@ -0,0 +1,94 @@
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// SchemaVersion is the version of the mapping made by NewBleveIndexer
+// bump it when a field changes so older indexes are rebuilt
+//
+//	1: mod_time stored as RFC1123 text, never indexed
+//	2: mod_time indexed as a datetime, name keyword field
+//	3: filename and path fields split in words
+//	4: content term vectors for phrases
+//	5: symbol, comment and string fields of source files
+//	6: hash and simhash fields telling the copies of a file
+//	7: metadata fields, author, title, created, pages, camera, keywords,
+//	   language, owner and perm
+//	8: content_words field of stemmed indexes for the suggestions
+//	9: content_words field of the indexes with a code analyzer too
+const SchemaVersion = 9
+
+const schemaKey = "__schema_version__"
+
+// SchemaVersion returns the version the index was created with
+// indexes without one predate the versioning
+func (bi *BleveIndexer) SchemaVersion() int {
+	data, err := bi.Index.GetInternal([]byte(schemaKey))
+	if err != nil || data == nil {
+		return 1
+	}
+	v, err := strconv.Atoi(string(data))
+	if err != nil {
+		return 1
+	}
+	return v
+}
+
+func (bi *BleveIndexer) saveSchemaVersion() error {
+	return bi.Index.SetInternal([]byte(schemaKey), []byte(strconv.Itoa(SchemaVersion)))
+}
+
+// Outdated reports if the index was made with an older schema
+func (bi *BleveIndexer) Outdated() bool {
+	return bi.SchemaVersion() < SchemaVersion
+}
+
+// Migrate rebuilds an outdated index of folderPath with the current schema
+// the mapping of a bleve index can not change, so the index is created again
+// with the same extensions and options and a running checkpoint is saved
+// for the coordinator to fill it like an interrupted scan
+// old is closed and kept aside in index/<name>.old while the new one is
+// made, it is put back if that fails and removed once it succeeds
+func Migrate(folderPath string, extensions map[string]bool, old *BleveIndexer) (*BleveIndexer, error) {
+	indexPath := "index/" + filepath.Base(folderPath)
+	backupPath := indexPath + ".old"
+	opts := old.Options
+	if err := old.Index.Close(); err != nil {
+		return nil, err
+	}
+	os.RemoveAll(backupPath)
+	if err := os.Rename(indexPath, backupPath); err != nil {
+		return nil, err
+	}
+	// restore puts the old index back when the new one can not be made
+	restore := func(err error) error {
+		os.RemoveAll(indexPath)
+		if rerr := os.Rename(backupPath, indexPath); rerr != nil {
+			return fmt.Errorf("%v, and the old index could not be restored: %v", err, rerr)
+		}
+		return err
+	}
+	bi, err := NewBleveIndexer(folderPath, extensions, opts)
+	if err != nil {
+		return nil, restore(err)
+	}
+	err = bi.SaveCheckpoint(&ScanCheckpoint{
+		Root:      folderPath,
+		State:     ScanRunning,
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		bi.Close()
+		return nil, restore(err)
+	}
+	// the old index can not be searched or resumed with the new schema
+	if err := os.RemoveAll(backupPath); err != nil {
+		fmt.Printf("Error removing the old index %s: %v\n", backupPath, err)
+	}
+	return bi, nil
+}
@@ -0,0 +1,37 @@
+package indexer
+
+import (
+	"GoSeek/internal/embed"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+)
+
+// vectorField holds the embeddings of the chunks of a document
+const vectorField = "vectors"
+
+// newEmbedder returns the embedder of the options, nil without one
+func newEmbedder(opts IndexOptions) (embed.Embedder, error) {
+	if opts.Embedder == "" {
+		return nil, nil
+	}
+	return embed.New(opts.Embedder)
+}
+
+// addVectorFields maps the embeddings in every document type
+func addVectorFields(m *mapping.IndexMappingImpl, dims int) {
+	addVectorField(m.DefaultMapping, dims)
+	for _, dm := range m.TypeMapping {
+		addVectorField(dm, dims)
+	}
+}
+
+// Semantic reports if the index has embeddings to search by meaning
+func (bi *BleveIndexer) Semantic() bool {
+	return bi.embedder != nil
+}
+
+// Embedder returns the embedder of the documents, nil without one
+// the readers embed the documents before they are indexed
+func (bi *BleveIndexer) Embedder() embed.Embedder {
+	return bi.embedder
+}
@@ -0,0 +1,357 @@
+//go:build !vectors
+
+package indexer
+
+import (
+	"GoSeek/internal/embed"
+	"GoSeek/internal/models"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// Without the vectors build tag Bleve has no vector fields (they need
+// the FAISS library), the embeddings are kept in the internal key space
+// with a key per document, and in memory where the kNN search compares
+// the query to all of them
+
+const vectorKeyPrefix = "__vectors__/"
+
+func vectorKey(id string) []byte {
+	return []byte(vectorKeyPrefix + id)
+}
+
+// vectorStore holds the embeddings of the chunks of every document
+// the changes of a batch wait in pending until it is committed,
+// nil vectors remove the document
+type vectorStore struct {
+	mu      sync.RWMutex
+	docs    map[string][][]float32
+	pending map[*bleve.Batch]map[string][][]float32
+}
+
+func newVectorStore() *vectorStore {
+	return &vectorStore{
+		docs:    make(map[string][][]float32),
+		pending: make(map[*bleve.Batch]map[string][][]float32),
+	}
+}
+
+func (s *vectorStore) set(id string, vectors [][]float32) {
+	s.mu.Lock()
+	s.docs[id] = vectors
+	s.mu.Unlock()
+}
+
+func (s *vectorStore) remove(id string) {
+	s.mu.Lock()
+	delete(s.docs, id)
+	s.mu.Unlock()
+}
+
+// stage keeps the change of a document until its batch is committed
+func (s *vectorStore) stage(batch *bleve.Batch, id string, vectors [][]float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changes := s.pending[batch]
+	if changes == nil {
+		changes = make(map[string][][]float32)
+		s.pending[batch] = changes
+	}
+	changes[id] = vectors
+}
+
+// done applies the changes of a committed batch, or drops them
+func (s *vectorStore) done(batch *bleve.Batch, committed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if committed {
+		for id, vectors := range s.pending[batch] {
+			if vectors == nil {
+				delete(s.docs, id)
+			} else {
+				s.docs[id] = vectors
+			}
+		}
+	}
+	delete(s.pending, batch)
+}
+
+// commitVectors makes the embeddings of a batch searched
+// once the index has its documents
+func (bi *BleveIndexer) commitVectors(batch *bleve.Batch, committed bool) {
+	if bi.embedder == nil {
+		return
+	}
+	bi.vectors.done(batch, committed)
+}
+
+func addVectorField(dm *mapping.DocumentMapping, dims int) {
+	// kept out of the index, the dynamic mapping would make numbers of them
+	dm.AddSubDocumentMapping(vectorField, bleve.NewDocumentDisabledMapping())
+}
+
+// storeVectors saves the embeddings with the batch of the document
+func (bi *BleveIndexer) storeVectors(batch *bleve.Batch, doc *models.Document) {
+	if bi.embedder == nil {
+		return
+	}
+	if len(doc.Vectors) == 0 {
+		bi.deleteVectors(batch, doc.Path)
+		return
+	}
+	batch.SetInternal(vectorKey(doc.Path), encodeVectors(doc.Vectors))
+	bi.vectors.stage(batch, doc.Path, doc.Vectors)
+}
+
+// deleteVectors removes the embeddings of a document
+// with the batch, or at once if it is nil
+func (bi *BleveIndexer) deleteVectors(batch *bleve.Batch, id string) {
+	if bi.embedder == nil {
+		return
+	}
+	if batch != nil {
+		batch.DeleteInternal(vectorKey(id))
+		bi.vectors.stage(batch, id, nil)
+		return
+	}
+	if err := bi.Index.DeleteInternal(vectorKey(id)); err != nil {
+		fmt.Printf("Error deleting the embeddings of %s: %v\n", id, err)
+		return
+	}
+	bi.vectors.remove(id)
+}
+
+// loadVectors reads the embeddings of every document of the index
+func (bi *BleveIndexer) loadVectors() error {
+	if bi.embedder == nil {
+		return nil
+	}
+	idx, err := bi.Index.Advanced()
+	if err != nil {
+		return err
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	ids, err := reader.DocIDReaderAll()
+	if err != nil {
+		return err
+	}
+	defer ids.Close()
+	for {
+		internal, err := ids.Next()
+		if err != nil || internal == nil {
+			return err
+		}
+		id, err := reader.ExternalID(internal)
+		if err != nil {
+			return err
+		}
+		data, err := reader.GetInternal(vectorKey(id))
+		if err != nil {
+			return err
+		}
+		if vectors, ok := decodeVectors(data); ok {
+			bi.vectors.set(id, vectors)
+		}
+	}
+}
+
+// encodeVectors writes the count, the dimensions and the values
+// in little endian
+func encodeVectors(vectors [][]float32) []byte {
+	dims := len(vectors[0])
+	data := make([]byte, 8, 8+4*dims*len(vectors))
+	binary.LittleEndian.PutUint32(data, uint32(len(vectors)))
+	binary.LittleEndian.PutUint32(data[4:], uint32(dims))
+	for _, vec := range vectors {
+		for _, v := range vec {
+			data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+		}
+	}
+	return data
+}
+
+func decodeVectors(data []byte) ([][]float32, bool) {
+	if len(data) < 8 {
+		return nil, false
+	}
+	count := int(binary.LittleEndian.Uint32(data))
+	dims := int(binary.LittleEndian.Uint32(data[4:]))
+	data = data[8:]
+	if len(data) != 4*count*dims {
+		return nil, false
+	}
+	vectors := make([][]float32, count)
+	for i := range vectors {
+		vec := make([]float32, dims)
+		for j := range vec {
+			vec[j] = math.Float32frombits(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		}
+		vectors[i] = vec
+	}
+	return vectors, true
+}
+
+// AddSimilar adds the k documents closest to the meaning of text to the
+// results of the request, scored by their similarity times boost
+// only documents matching filter are compared when it is not nil
+func (bi *BleveIndexer) AddSimilar(req *bleve.SearchRequest, text string, k int, boost float64, filter query.Query) {
+	similar := &similarQuery{
+		store:  bi.vectors,
+		vector: bi.embedder.Embed(text),
+		k:      k,
+		boost:  boost,
+		filter: filter,
+	}
+	if _, none := req.Query.(*query.MatchNoneQuery); none {
+		req.Query = similar
+		return
+	}
+	req.Query = bleve.NewDisjunctionQuery(req.Query, similar)
+}
+
+// similarQuery matches the k nearest documents of vector
+// a document is as close as its closest chunk
+type similarQuery struct {
+	store  *vectorStore
+	vector []float32
+	k      int
+	boost  float64
+	filter query.Query
+}
+
+func (q *similarQuery) Searcher(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, options search.SearcherOptions) (search.Searcher, error) {
+	var allowed map[string]bool
+	if q.filter != nil {
+		var err error
+		allowed, err = matchingIDs(ctx, i, m, q.filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	scores := q.store.nearest(q.vector, q.k, allowed)
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	s, err := searcher.NewDocIDSearcher(ctx, i, ids, q.boost, options)
+	if err != nil {
+		return nil, err
+	}
+	return &scoredSearcher{Searcher: s, reader: i, scores: scores}, nil
+}
+
+// matchingIDs are the documents matching q
+func matchingIDs(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, q query.Query) (map[string]bool, error) {
+	s, err := q.Searcher(ctx, i, m, search.SearcherOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	sctx := &search.SearchContext{DocumentMatchPool: search.NewDocumentMatchPool(s.DocumentMatchPoolSize(), 0)}
+	ids := make(map[string]bool)
+	for {
+		d, err := s.Next(sctx)
+		if err != nil || d == nil {
+			return ids, err
+		}
+		id, err := i.ExternalID(d.IndexInternalID)
+		if err != nil {
+			return nil, err
+		}
+		ids[id] = true
+		sctx.DocumentMatchPool.Put(d)
+	}
+}
+
+// nearest compares vector to the chunks of every document
+// and returns the similarity of the k closest ones
+func (s *vectorStore) nearest(vector []float32, k int, allowed map[string]bool) map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	best := &scoreHeap{}
+	for id, vectors := range s.docs {
+		if allowed != nil && !allowed[id] {
+			continue
+		}
+		score := 0.0
+		for _, vec := range vectors {
+			score = max(score, embed.Cosine(vector, vec))
+		}
+		if score <= 0 {
+			continue
+		}
+		if best.Len() < k {
+			heap.Push(best, scoredID{id, score})
+		} else if score > (*best)[0].score {
+			(*best)[0] = scoredID{id, score}
+			heap.Fix(best, 0)
+		}
+	}
+	scores := make(map[string]float64, best.Len())
+	for _, s := range *best {
+		scores[s.id] = s.score
+	}
+	return scores
+}
+
+type scoredID struct {
+	id    string
+	score float64
+}
+
+// scoreHeap has the lowest score on top
+type scoreHeap []scoredID
+
+func (h scoreHeap) Len() int           { return len(h) }
+func (h scoreHeap) Less(i, j int) bool { return h[i].score < h[j].score }
+func (h scoreHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *scoreHeap) Push(x any)        { *h = append(*h, x.(scoredID)) }
+func (h *scoreHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// scoredSearcher gives the documents of a DocIDSearcher their similarity
+type scoredSearcher struct {
+	search.Searcher
+	reader index.IndexReader
+	scores map[string]float64
+}
+
+func (s *scoredSearcher) Next(ctx *search.SearchContext) (*search.DocumentMatch, error) {
+	d, err := s.Searcher.Next(ctx)
+	return s.score(d), err
+}
+
+func (s *scoredSearcher) Advance(ctx *search.SearchContext, ID index.IndexInternalID) (*search.DocumentMatch, error) {
+	d, err := s.Searcher.Advance(ctx, ID)
+	return s.score(d), err
+}
+
+func (s *scoredSearcher) score(d *search.DocumentMatch) *search.DocumentMatch {
+	if d == nil {
+		return nil
+	}
+	if id, err := s.reader.ExternalID(d.IndexInternalID); err == nil {
+		d.Score *= s.scores[id]
+	}
+	return d
+}
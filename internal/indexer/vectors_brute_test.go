@@ -0,0 +1,84 @@
+//go:build !vectors
+
+package indexer
+
+import (
+	"GoSeek/internal/embed"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+func unit(values ...float32) []float32 {
+	return embed.Normalize(values)
+}
+
+func newTestStore() *vectorStore {
+	s := newVectorStore()
+	s.set("east", [][]float32{unit(1, 0)})
+	s.set("north-east", [][]float32{unit(1, 1)})
+	// a document is as close as its closest chunk
+	s.set("chunks", [][]float32{unit(-1, 0), unit(0.9, 0.1)})
+	s.set("north", [][]float32{unit(0, 1)})
+	s.set("west", [][]float32{unit(-1, 0)})
+	return s
+}
+
+func TestNearest(t *testing.T) {
+	s := newTestStore()
+	got := s.nearest(unit(1, 0), 2, nil)
+	if ids := slices.Sorted(maps.Keys(got)); !slices.Equal(ids, []string{"chunks", "east"}) {
+		t.Errorf("nearest = %v, want chunks and east", got)
+	}
+	if got["east"] < got["chunks"] {
+		t.Errorf("east scores %v under chunks %v", got["east"], got["chunks"])
+	}
+	// documents facing away are never near
+	all := s.nearest(unit(1, 0), 10, nil)
+	if _, ok := all["west"]; ok {
+		t.Errorf("west is near east: %v", all)
+	}
+	if _, ok := all["north"]; ok {
+		t.Errorf("north at a right angle is near east: %v", all)
+	}
+}
+
+func TestNearestAllowed(t *testing.T) {
+	s := newTestStore()
+	allowed := map[string]bool{"north": true, "west": true}
+	got := s.nearest(unit(1, 0.2), 2, allowed)
+	if ids := slices.Sorted(maps.Keys(got)); !slices.Equal(ids, []string{"north"}) {
+		t.Errorf("nearest of north and west = %v, want north", got)
+	}
+	if got := s.nearest(unit(1, 0), 3, map[string]bool{}); len(got) != 0 {
+		t.Errorf("nearest with nothing allowed = %v", got)
+	}
+}
+
+func TestVectorStoreBatches(t *testing.T) {
+	s := newTestStore()
+	committed, failed := new(bleve.Batch), new(bleve.Batch)
+	s.stage(committed, "south", [][]float32{unit(0, -1)})
+	s.stage(committed, "east", nil)
+	s.stage(failed, "west", nil)
+	if _, ok := s.nearest(unit(0, -1), 1, nil)["south"]; ok {
+		t.Error("the vectors of a batch are searched before it is committed")
+	}
+	s.done(committed, true)
+	s.done(failed, false)
+	got := s.nearest(unit(0, -1), 1, nil)
+	if _, ok := got["south"]; !ok {
+		t.Errorf("the vectors of a committed batch are not searched: %v", got)
+	}
+	if _, ok := s.docs["east"]; ok {
+		t.Error("a document deleted by a committed batch is still searched")
+	}
+	if _, ok := s.docs["west"]; !ok {
+		t.Error("a document deleted by a failed batch is gone")
+	}
+	if len(s.pending) != 0 {
+		t.Errorf("%d batches are still pending", len(s.pending))
+	}
+}
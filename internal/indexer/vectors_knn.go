@@ -0,0 +1,52 @@
+//go:build vectors
+
+package indexer
+
+import (
+	"GoSeek/internal/models"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// With the vectors build tag the embeddings are a Bleve vector field
+// and the kNN search runs on its FAISS index
+
+// vectorStore is only needed without vector fields
+type vectorStore struct{}
+
+func newVectorStore() *vectorStore {
+	return nil
+}
+
+func addVectorField(dm *mapping.DocumentMapping, dims int) {
+	field := mapping.NewVectorFieldMapping()
+	field.Dims = dims
+	field.Similarity = index.CosineSimilarity
+	dm.AddFieldMappingsAt(vectorField, field)
+}
+
+// storeVectors has nothing to do, the field indexes them
+func (bi *BleveIndexer) storeVectors(batch *bleve.Batch, doc *models.Document) {}
+
+func (bi *BleveIndexer) deleteVectors(batch *bleve.Batch, id string) {}
+
+func (bi *BleveIndexer) commitVectors(batch *bleve.Batch, committed bool) {}
+
+func (bi *BleveIndexer) loadVectors() error {
+	return nil
+}
+
+// AddSimilar adds the k chunks closest to the meaning of text to the
+// results of the request, scored by their similarity times boost
+// only documents matching filter are compared when it is not nil
+func (bi *BleveIndexer) AddSimilar(req *bleve.SearchRequest, text string, k int, boost float64, filter query.Query) {
+	vec := bi.embedder.Embed(text)
+	if filter == nil {
+		req.AddKNN(vectorField, vec, int64(k), boost)
+		return
+	}
+	req.AddKNNWithFilter(vectorField, vec, int64(k), boost, filter)
+}
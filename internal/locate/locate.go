@@ -0,0 +1,339 @@
+package locate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mode is how a name is matched
+type Mode int
+
+const (
+	Auto      Mode = iota // every mode, best matches first
+	Prefix                // the name starts with the query
+	Substring             // the name contains the query
+	Fuzzy                 // the letters of the query in order or a close typo
+)
+
+func ParseMode(s string) (Mode, bool) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return Auto, true
+	case "prefix":
+		return Prefix, true
+	case "substring":
+		return Substring, true
+	case "fuzzy":
+		return Fuzzy, true
+	}
+	return Auto, false
+}
+
+// Match is a path found by name, higher scores are better
+type Match struct {
+	Path  string
+	Score int
+}
+
+// scores of the kinds of matches
+const (
+	scoreExact     = 1000
+	scorePrefix    = 800
+	scoreSubstring = 600
+	scoreFuzzy     = 400
+)
+
+type entry struct {
+	name string // lower case base name
+	path string
+}
+
+// Index keeps every path of an indexed folder for locate style search
+// paths are relative like the document ids
+// names are kept sorted so a prefix is a binary search away
+// the other modes scan the names, which stays fast for millions of files
+type Index struct {
+	mu      sync.RWMutex
+	paths   map[string]uint32 // path to the generation that saw it
+	gen     uint32
+	sorted  []entry
+	stale   bool // sorted needs a rebuild
+	changed bool // not saved since the last change
+}
+
+func New() *Index {
+	return &Index{paths: make(map[string]uint32)}
+}
+
+// Add records a path seen by the walker or the watcher
+func (ix *Index) Add(path string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if gen, ok := ix.paths[path]; ok && gen == ix.gen {
+		return
+	}
+	_, known := ix.paths[path]
+	ix.paths[path] = ix.gen
+	if !known {
+		ix.stale = true
+		ix.changed = true
+	}
+}
+
+// Remove drops the path and everything under it
+func (ix *Index) Remove(path string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	prefix := path + string(filepath.Separator)
+	for p := range ix.paths {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(ix.paths, p)
+			ix.stale = true
+			ix.changed = true
+		}
+	}
+}
+
+// NewGeneration starts a full scan, the paths it does not see again
+// are dropped by DropOlder when it ends
+func (ix *Index) NewGeneration() uint32 {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.gen++
+	return ix.gen
+}
+
+// DropOlder removes the paths not seen since generation gen
+func (ix *Index) DropOlder(gen uint32) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for p, g := range ix.paths {
+		if g < gen {
+			delete(ix.paths, p)
+			ix.stale = true
+			ix.changed = true
+		}
+	}
+}
+
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.paths)
+}
+
+// Search returns the best limit paths whose name matches q
+func (ix *Index) Search(q string, mode Mode, limit int) []Match {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if q == "" || limit <= 0 {
+		return nil
+	}
+	entries := ix.entries()
+	var matches []Match
+	if mode == Prefix {
+		i := sort.Search(len(entries), func(i int) bool { return entries[i].name >= q })
+		for ; i < len(entries) && strings.HasPrefix(entries[i].name, q); i++ {
+			matches = append(matches, Match{Path: entries[i].path, Score: nameScore(entries[i].name, q, mode)})
+		}
+	} else {
+		for _, e := range entries {
+			if score := nameScore(e.name, q, mode); score > 0 {
+				matches = append(matches, Match{Path: e.path, Score: score})
+			}
+		}
+	}
+	SortMatches(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// SortMatches orders by score then by shorter path
+func SortMatches(matches []Match) {
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) < len(b.Path)
+		}
+		return a.Path < b.Path
+	})
+}
+
+// nameScore returns how well name matches q in the mode, 0 if it does not
+func nameScore(name, q string, mode Mode) int {
+	if name == q {
+		return scoreExact
+	}
+	if mode == Prefix || mode == Auto {
+		if strings.HasPrefix(name, q) {
+			return scorePrefix - len(name)
+		}
+		if mode == Prefix {
+			return 0
+		}
+	}
+	if mode == Substring || mode == Auto {
+		if i := strings.Index(name, q); i >= 0 {
+			return scoreSubstring - i - len(name)
+		}
+		if mode == Substring {
+			return 0
+		}
+	}
+	if gaps, ok := subsequence(name, q); ok {
+		return scoreFuzzy - gaps - len(name)
+	}
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if len(stem) > len(q) {
+		stem = stem[:len(q)]
+	}
+	if d := editDistance(stem, q); d <= maxEdits(q) {
+		return scoreFuzzy - 100*d - len(name)
+	}
+	return 0
+}
+
+// subsequence reports if the letters of q appear in order in name
+// and counts the letters skipped between them
+func subsequence(name, q string) (int, bool) {
+	gaps, i := 0, 0
+	started := false
+	for j := 0; j < len(name) && i < len(q); j++ {
+		if name[j] == q[i] {
+			i++
+			started = true
+		} else if started {
+			gaps++
+		}
+	}
+	return gaps, i == len(q)
+}
+
+func maxEdits(q string) int {
+	if len(q) < 4 {
+		return 0
+	}
+	if len(q) < 8 {
+		return 1
+	}
+	return 2
+}
+
+// editDistance counts the insertions, deletions, substitutions
+// and swaps of two neighbour letters between a and b
+func editDistance(a, b string) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d = min(d, rows[i-2][j-2]+1)
+			}
+			rows[i][j] = d
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+// entries returns the names sorted, rebuilt after changes
+func (ix *Index) entries() []entry {
+	ix.mu.RLock()
+	if !ix.stale && ix.sorted != nil {
+		defer ix.mu.RUnlock()
+		return ix.sorted
+	}
+	ix.mu.RUnlock()
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.stale || ix.sorted == nil {
+		sorted := make([]entry, 0, len(ix.paths))
+		for p := range ix.paths {
+			sorted = append(sorted, entry{name: strings.ToLower(filepath.Base(p)), path: p})
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].name != sorted[j].name {
+				return sorted[i].name < sorted[j].name
+			}
+			return sorted[i].path < sorted[j].path
+		})
+		ix.sorted = sorted
+		ix.stale = false
+	}
+	return ix.sorted
+}
+
+// Changed reports if there are changes to save
+func (ix *Index) Changed() bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.changed
+}
+
+// Marshal returns the paths compressed for the internal store of the index
+// and marks them as saved
+func (ix *Index) Marshal() ([]byte, error) {
+	ix.mu.Lock()
+	paths := make([]string, 0, len(ix.paths))
+	for p := range ix.paths {
+		paths = append(paths, p)
+	}
+	ix.changed = false
+	ix.mu.Unlock()
+	// sorted paths share prefixes so they compress well
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, p := range paths {
+		zw.Write([]byte(p))
+		zw.Write([]byte{'\n'})
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal loads the paths saved by Marshal
+func Unmarshal(data []byte) (*Index, error) {
+	ix := New()
+	if len(data) == 0 {
+		return ix, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(string(raw), "\n") {
+		if p != "" {
+			ix.paths[p] = 0
+		}
+	}
+	ix.stale = true
+	return ix, nil
+}
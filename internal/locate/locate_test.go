@@ -0,0 +1,66 @@
+package locate
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"report", "report", 0},
+		{"report", "reprot", 1}, // swap of two neighbour letters
+		{"report", "repor", 1},
+		{"report", "reports", 1},
+		{"report", "rapport", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestNameScore(t *testing.T) {
+	tests := []struct {
+		name, q string
+		mode    Mode
+		matches bool
+	}{
+		{"report.txt", "report.txt", Prefix, true},
+		{"report.txt", "rep", Prefix, true},
+		{"my_report.txt", "rep", Prefix, false},
+		{"my_report.txt", "rep", Substring, true},
+		{"report.txt", "xyz", Substring, false},
+		{"report.txt", "rpt", Fuzzy, true},    // letters in order
+		{"report.txt", "reprot", Fuzzy, true}, // one typo
+		{"report.txt", "rpoert", Fuzzy, false},
+		{"report.txt", "abc", Auto, false},
+	}
+	for _, tt := range tests {
+		if got := nameScore(tt.name, tt.q, tt.mode) > 0; got != tt.matches {
+			t.Errorf("nameScore(%q, %q, %d) matches = %v, want %v", tt.name, tt.q, tt.mode, got, tt.matches)
+		}
+	}
+}
+
+// the better kinds of matches rank first in Auto mode
+func TestNameScoreOrder(t *testing.T) {
+	ranked := []string{"notes", "notes.txt", "old_notes.txt", "n_o_t_e_s.txt"}
+	for i := 1; i < len(ranked); i++ {
+		prev, cur := nameScore(ranked[i-1], "notes", Auto), nameScore(ranked[i], "notes", Auto)
+		if prev <= cur {
+			t.Errorf("%q scores %d, not above %q with %d", ranked[i-1], prev, ranked[i], cur)
+		}
+	}
+	// shorter names first among the same kind of match
+	if nameScore("notes.md", "not", Auto) <= nameScore("notes.txt", "not", Auto) {
+		t.Error("shorter prefix match does not rank first")
+	}
+}
@@ -0,0 +1,135 @@
+package metadata
+
+import (
+	"GoSeek/internal/models"
+	"encoding/binary"
+	"strings"
+)
+
+// EXIF tags read from photos
+const (
+	tagDescription = 0x010e
+	tagMake        = 0x010f
+	tagModel       = 0x0110
+	tagDateTime    = 0x0132
+	tagArtist      = 0x013b
+	tagExifIFD     = 0x8769
+	tagDateTaken   = 0x9003
+)
+
+// maxIFDEntries bounds the entries read from a directory of a broken file
+const maxIFDEntries = 512
+
+// readEXIF reads the camera, author, description and date of a JPEG
+// or TIFF photo, the date it was taken is its creation date
+func readEXIF(doc *models.Document) {
+	tiff, ok := exifTIFF(doc.Content)
+	if !ok {
+		return
+	}
+	tags, ok := readIFDs(tiff)
+	if !ok {
+		return
+	}
+	maker, model := tags[tagMake], tags[tagModel]
+	// most models repeat the make, "Canon" "Canon EOS 5D"
+	if strings.HasPrefix(strings.ToLower(model), strings.ToLower(maker)) {
+		maker = ""
+	}
+	setText(&doc.Camera, strings.TrimSpace(maker+" "+model))
+	setText(&doc.Author, tags[tagArtist])
+	setText(&doc.Title, tags[tagDescription])
+	for _, tag := range []uint16{tagDateTaken, tagDateTime} {
+		if t, ok := parseDate(tags[tag]); ok {
+			setCreated(doc, t)
+		}
+	}
+}
+
+// exifTIFF finds the TIFF structure holding the EXIF, the file itself
+// for a TIFF and the APP1 segment of a JPEG
+func exifTIFF(content string) ([]byte, bool) {
+	if strings.HasPrefix(content, "II*\x00") || strings.HasPrefix(content, "MM\x00*") {
+		return []byte(content), true
+	}
+	if !strings.HasPrefix(content, "\xff\xd8") {
+		return nil, false
+	}
+	for pos := 2; pos+4 <= len(content); {
+		if content[pos] != 0xff {
+			return nil, false
+		}
+		marker := content[pos+1]
+		// the image data follows, no metadata after it
+		if marker == 0xda || marker == 0xd9 {
+			return nil, false
+		}
+		size := int(content[pos+2])<<8 | int(content[pos+3])
+		end := pos + 2 + size
+		if size < 2 || end > len(content) {
+			return nil, false
+		}
+		segment := content[pos+4 : end]
+		if marker == 0xe1 && strings.HasPrefix(segment, "Exif\x00\x00") {
+			return []byte(segment[6:]), true
+		}
+		pos = end
+	}
+	return nil, false
+}
+
+// readIFDs reads the text tags of the first directory and of its
+// EXIF sub directory
+func readIFDs(tiff []byte) (map[uint16]string, bool) {
+	if len(tiff) < 8 {
+		return nil, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, false
+	}
+	tags := make(map[uint16]string)
+	offset := order.Uint32(tiff[4:8])
+	exif := readIFD(tiff, order, offset, tags)
+	if exif > 0 && exif != offset {
+		readIFD(tiff, order, exif, tags)
+	}
+	return tags, true
+}
+
+// readIFD adds the ASCII tags of the directory at offset to tags
+// and returns the offset of the EXIF sub directory, 0 without one
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32, tags map[uint16]string) (exif uint32) {
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return 0
+	}
+	n := int(order.Uint16(tiff[offset:]))
+	entries := tiff[offset+2:]
+	for i := range min(n, maxIFDEntries) {
+		if (i+1)*12 > len(entries) {
+			break
+		}
+		entry := entries[i*12 : (i+1)*12]
+		tag, kind := order.Uint16(entry), order.Uint16(entry[2:])
+		count, value := order.Uint32(entry[4:]), order.Uint32(entry[8:])
+		switch {
+		case tag == tagExifIFD:
+			exif = value
+		case kind == 2: // ASCII
+			data := entry[8:12]
+			if count > 4 {
+				if uint64(value)+uint64(count) > uint64(len(tiff)) {
+					continue
+				}
+				data = tiff[value : value+count]
+			}
+			tags[tag] = strings.TrimRight(string(data[:min(int(count), len(data))]), "\x00 ")
+		}
+	}
+	return exif
+}
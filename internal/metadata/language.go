@@ -0,0 +1,63 @@
+package metadata
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// extLanguages are the languages of scripts and source files by extension
+var extLanguages = map[string]string{
+	".sh": "shell", ".bash": "shell", ".zsh": "shell", ".ksh": "shell", ".fish": "shell",
+	".ps1": "powershell", ".bat": "batch", ".cmd": "batch",
+	".py": "python", ".pyw": "python",
+	".js": "javascript", ".mjs": "javascript", ".cjs": "javascript", ".jsx": "javascript",
+	".ts": "typescript", ".tsx": "typescript",
+	".go": "go", ".java": "java", ".kt": "kotlin", ".kts": "kotlin", ".scala": "scala",
+	".c": "c", ".h": "c", ".cpp": "cpp", ".cc": "cpp", ".cxx": "cpp", ".hpp": "cpp",
+	".cs": "csharp", ".rs": "rust", ".swift": "swift",
+	".rb": "ruby", ".php": "php", ".pl": "perl", ".pm": "perl", ".lua": "lua",
+	".r": "r", ".sql": "sql",
+}
+
+// interpreters are the languages of the interpreters named differently
+var interpreters = map[string]string{
+	"sh": "shell", "bash": "shell", "zsh": "shell", "dash": "shell", "ksh": "shell", "fish": "shell",
+	"node": "javascript", "nodejs": "javascript", "deno": "typescript",
+	"pwsh": "powershell", "rscript": "r",
+}
+
+// language is the language of a script by its shebang line,
+// then of a source file by its extension, empty for other files
+func language(content, ext string) string {
+	if line, ok := strings.CutPrefix(content, "#!"); ok {
+		line, _, _ = strings.Cut(line, "\n")
+		if lang := shebangLanguage(line); lang != "" {
+			return lang
+		}
+	}
+	return extLanguages[strings.ToLower(ext)]
+}
+
+// shebangLanguage reads lines like /usr/bin/env -S python3 -u
+func shebangLanguage(line string) string {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return ""
+	}
+	name := filepath.Base(args[0])
+	if name == "env" {
+		name = ""
+		for _, arg := range args[1:] {
+			if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+				name = filepath.Base(arg)
+				break
+			}
+		}
+	}
+	// python3.12 is python
+	name = strings.ToLower(strings.TrimRight(name, "0123456789."))
+	if lang, ok := interpreters[name]; ok {
+		return lang
+	}
+	return name
+}
@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"GoSeek/internal/models"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// readFrontMatter reads the YAML (---) or TOML (+++) header of a
+// Markdown file, its top level title, author, date and tags
+func readFrontMatter(doc *models.Document) {
+	content := strings.TrimPrefix(doc.Content, "\ufeff")
+	delim := ""
+	for _, d := range []string{"---", "+++"} {
+		if strings.HasPrefix(content, d+"\n") || strings.HasPrefix(content, d+"\r\n") {
+			delim = d
+		}
+	}
+	if delim == "" {
+		return
+	}
+	lines := strings.Split(content, "\n")[1:]
+	list := "" // key of the YAML list the lines starting with - belong to
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if line == delim {
+			return
+		}
+		trimmed := strings.TrimSpace(line)
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok && list != "" {
+			setFrontMatter(doc, list, []string{unquote(item)})
+			continue
+		}
+		if line != trimmed { // nested keys
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if delim == "+++" {
+			key, value, ok = strings.Cut(line, "=")
+		}
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		list = ""
+		if value == "" {
+			list = key
+			continue
+		}
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			var items []string
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = unquote(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			setFrontMatter(doc, key, items)
+			continue
+		}
+		setFrontMatter(doc, key, []string{unquote(value)})
+	}
+}
+
+func setFrontMatter(doc *models.Document, key string, values []string) {
+	switch key {
+	case "title":
+		setText(&doc.Title, strings.Join(values, " "))
+	case "author", "authors":
+		// the items of a list come one at a time
+		names := strings.Join(values, ", ")
+		switch {
+		case names == "":
+		case doc.Author == "":
+			doc.Author = names
+		default:
+			doc.Author += ", " + names
+		}
+	case "date", "created":
+		if len(values) > 0 {
+			if t, ok := parseDate(values[0]); ok {
+				setCreated(doc, t)
+			}
+		}
+	case "tags", "keywords", "categories":
+		doc.Keywords = append(doc.Keywords, values...)
+	}
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	}
+	return strings.TrimSpace(s)
+}
+
+var (
+	htmlTitle = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlMeta  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	htmlAttr  = regexp.MustCompile(`(?is)(name|content)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// readHTML reads the title and the author and keywords meta tags of a page
+func readHTML(doc *models.Document) {
+	if m := htmlTitle.FindStringSubmatch(doc.Content); m != nil {
+		setText(&doc.Title, html.UnescapeString(strings.Join(strings.Fields(m[1]), " ")))
+	}
+	for _, tag := range htmlMeta.FindAllString(doc.Content, -1) {
+		var name, content string
+		for _, attr := range htmlAttr.FindAllStringSubmatch(tag, -1) {
+			value := html.UnescapeString(attr[2] + attr[3])
+			if strings.EqualFold(attr[1], "name") {
+				name = strings.ToLower(value)
+			} else {
+				content = value
+			}
+		}
+		switch name {
+		case "author":
+			setText(&doc.Author, content)
+		case "keywords":
+			if doc.Keywords == nil {
+				doc.Keywords = splitKeywords(content)
+			}
+		}
+	}
+}
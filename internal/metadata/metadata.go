@@ -0,0 +1,103 @@
+// Package metadata reads what a file tells about itself so it can be
+// indexed in fields of its own: the author, title, creation date and
+// page count of documents, the EXIF of photos, the front matter of
+// Markdown, the language of scripts, and the owner and permissions
+// of every file.
+// Everything is read from the content already loaded, a file that
+// can not be understood simply has no metadata.
+package metadata
+
+import (
+	"GoSeek/internal/models"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Extract fills the metadata fields of doc, the file at filePath
+func Extract(doc *models.Document, filePath string, info os.FileInfo) {
+	doc.Owner = owner(info)
+	doc.Perm = strconv.FormatUint(uint64(info.Mode().Perm()), 8)
+	switch ext := strings.ToLower(doc.Extension); ext {
+	case ".pdf":
+		readPDF(doc)
+	case ".docx", ".xlsx", ".pptx":
+		readOOXML(doc)
+	case ".odt", ".ods", ".odp":
+		readODF(doc)
+	case ".jpg", ".jpeg", ".tif", ".tiff":
+		readEXIF(doc)
+	case ".md", ".markdown":
+		readFrontMatter(doc)
+	case ".html", ".htm":
+		readHTML(doc)
+	}
+	doc.Language = language(doc.Content, doc.Extension)
+}
+
+// Binary reports if the files of ext are not text
+// their content is only read for the metadata, it is not indexed
+func Binary(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".pdf", ".docx", ".xlsx", ".pptx", ".odt", ".ods", ".odp", ".jpg", ".jpeg", ".tif", ".tiff":
+		return true
+	}
+	return false
+}
+
+// setText keeps the first value found for a field
+func setText(field *string, value string) {
+	value = strings.TrimSpace(value)
+	if *field == "" && value != "" {
+		*field = value
+	}
+}
+
+func setPages(doc *models.Document, pages int) {
+	if doc.Pages == nil && pages > 0 {
+		doc.Pages = &pages
+	}
+}
+
+func setCreated(doc *models.Document, t time.Time) {
+	if doc.Created == nil && !t.IsZero() {
+		doc.Created = &t
+	}
+}
+
+// splitKeywords reads a list of keywords separated by commas or semicolons
+func splitKeywords(s string) []string {
+	var keywords []string
+	for _, k := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if k = strings.TrimSpace(k); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
+}
+
+// dateLayouts are the dates documents are written with, the longest first
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006:01:02 15:04:05", // EXIF
+	"2006-01-02",
+}
+
+// parseDate reads a date in one of dateLayouts, a date without
+// a time zone is a local one
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	// fractions of seconds or a zone the layouts miss
+	if len(s) > 19 {
+		return parseDate(s[:19])
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,92 @@
+package metadata
+
+import (
+	"GoSeek/internal/models"
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// maxPartSize bounds the metadata parts read from an office document
+const maxPartSize = 1 << 20
+
+// readOOXML reads the properties of Word, Excel and PowerPoint documents
+func readOOXML(doc *models.Document) {
+	files, ok := openZip(doc.Content)
+	if !ok {
+		return
+	}
+	var core struct {
+		Title    string `xml:"title"`
+		Creator  string `xml:"creator"`
+		Keywords string `xml:"keywords"`
+		Created  string `xml:"created"`
+	}
+	if readXML(files, "docProps/core.xml", &core) {
+		setText(&doc.Title, core.Title)
+		setText(&doc.Author, core.Creator)
+		doc.Keywords = splitKeywords(core.Keywords)
+		if t, ok := parseDate(core.Created); ok {
+			setCreated(doc, t)
+		}
+	}
+	var app struct {
+		Pages  int `xml:"Pages"`
+		Slides int `xml:"Slides"`
+	}
+	if readXML(files, "docProps/app.xml", &app) {
+		setPages(doc, max(app.Pages, app.Slides))
+	}
+}
+
+// readODF reads the properties of OpenDocument texts, sheets and slides
+func readODF(doc *models.Document) {
+	files, ok := openZip(doc.Content)
+	if !ok {
+		return
+	}
+	var meta struct {
+		Title    string   `xml:"meta>title"`
+		Creator  string   `xml:"meta>initial-creator"`
+		Created  string   `xml:"meta>creation-date"`
+		Keywords []string `xml:"meta>keyword"`
+		Stats    struct {
+			Pages int `xml:"page-count,attr"`
+		} `xml:"meta>document-statistic"`
+	}
+	if !readXML(files, "meta.xml", &meta) {
+		return
+	}
+	setText(&doc.Title, meta.Title)
+	setText(&doc.Author, meta.Creator)
+	doc.Keywords = meta.Keywords
+	if t, ok := parseDate(meta.Created); ok {
+		setCreated(doc, t)
+	}
+	setPages(doc, meta.Stats.Pages)
+}
+
+func openZip(content string) ([]*zip.File, bool) {
+	r, err := zip.NewReader(strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, false
+	}
+	return r.File, true
+}
+
+// readXML decodes the part at name of the archive into v
+func readXML(files []*zip.File, name string, v any) bool {
+	for _, f := range files {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return false
+		}
+		defer rc.Close()
+		return xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v) == nil
+	}
+	return false
+}
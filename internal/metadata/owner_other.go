@@ -0,0 +1,10 @@
+//go:build !unix
+
+package metadata
+
+import "os"
+
+// owner is not supported on this platform
+func owner(info os.FileInfo) string {
+	return ""
+}
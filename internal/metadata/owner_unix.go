@@ -0,0 +1,32 @@
+//go:build unix
+
+package metadata
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// userNames caches the names of the owners by uid
+var userNames sync.Map
+
+// owner is the name of the user owning the file, its uid without one
+func owner(info os.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	if name, ok := userNames.Load(uid); ok {
+		return name.(string)
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	userNames.Store(uid, name)
+	return name
+}
@@ -0,0 +1,89 @@
+package metadata
+
+import (
+	"GoSeek/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+var (
+	// a page object, not the /Pages tree holding them
+	pdfPage = regexp.MustCompile(`/Type\s*/Page\b`)
+	// the page count of a /Pages tree, the root one has the most
+	pdfCount = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	// literal strings of the document information dictionary
+	pdfInfo = regexp.MustCompile(`/(Title|Author|CreationDate|Keywords)\s*\(((?:\\.|[^\\)])*)\)`)
+)
+
+// readPDF reads the document information and counts the pages
+// of a PDF whose objects are not compressed in streams
+func readPDF(doc *models.Document) {
+	pages := len(pdfPage.FindAllStringIndex(doc.Content, -1))
+	if pages == 0 {
+		for _, m := range pdfCount.FindAllStringSubmatch(doc.Content, -1) {
+			n, _ := strconv.Atoi(m[1] + m[2])
+			pages = max(pages, n)
+		}
+	}
+	setPages(doc, pages)
+	for _, m := range pdfInfo.FindAllStringSubmatch(doc.Content, -1) {
+		value := pdfString(m[2])
+		switch m[1] {
+		case "Title":
+			setText(&doc.Title, value)
+		case "Author":
+			setText(&doc.Author, value)
+		case "CreationDate":
+			setCreated(doc, pdfDate(value))
+		case "Keywords":
+			if doc.Keywords == nil {
+				doc.Keywords = splitKeywords(value)
+			}
+		}
+	}
+}
+
+var pdfEscapes = strings.NewReplacer(`\(`, "(", `\)`, ")", `\\`, `\`, `\n`, "\n", `\r`, "\r", `\t`, "\t")
+
+// pdfString decodes a literal string, in PDFDocEncoding or in UTF-16
+// with a byte order mark
+func pdfString(s string) string {
+	s = pdfEscapes.Replace(s)
+	if !strings.HasPrefix(s, "\xfe\xff") {
+		return s
+	}
+	b := []byte(s[2:])
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// pdfDate reads dates like D:20240315103000+01'00'
+// the time zone is left out, the date is a local one
+func pdfDate(s string) time.Time {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	digits := 0
+	for digits < len(s) && digits < 14 && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	layout := "20060102150405"
+	switch {
+	case digits >= 14:
+	case digits >= 8:
+		digits = 8
+	case digits >= 4:
+		digits = 4
+	default:
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(layout[:digits], s[:digits], time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -1,22 +1,67 @@
 package models
 
-import "path/filepath"
+import (
+	"path/filepath"
+	"time"
+)
 
 type Document struct {
-	Path      string  `json:"path"`
-	Dir       string  `json:"dir"`
-	Size      int64   `json:"size"`
-	Score     float64 `json:"score"`
-	ModTime   string  `json:"mod_time"`
-	Extension string  `json:"extension"`
-	Content   string  `json:"content"`
+	Path      string    `json:"path"`
+	Name      string    `json:"name"`
+	Dir       string    `json:"dir"`
+	Size      int64     `json:"size"`
+	Score     float64   `json:"score"`
+	ModTime   time.Time `json:"mod_time"`
+	Extension string    `json:"extension"`
+	Content   string    `json:"content"`
+
+	// Definitions, comments and string literals of source files
+	Symbols  []string `json:"symbol,omitempty"`
+	Comments []string `json:"comment,omitempty"`
+	Strings  []string `json:"string,omitempty"`
+
+	// Metadata of the file, see package metadata
+	Author   string     `json:"author,omitempty"`
+	Title    string     `json:"title,omitempty"`
+	Created  *time.Time `json:"created,omitempty"` // nil when the file does not tell
+	Pages    *int       `json:"pages,omitempty"`   // nil when the file does not tell
+	Camera   string     `json:"camera,omitempty"`  // make and model of a photo
+	Keywords []string   `json:"keywords,omitempty"`
+	Language string     `json:"language,omitempty"` // of a script or source file
+	Owner    string     `json:"owner,omitempty"`
+	Perm     string     `json:"perm,omitempty"` // permissions in octal, like 644
+
+	// Hash of the content and SimHash of its words, see dedup.SimHash
+	// copies have the same Hash and near copies close SimHashes
+	Hash    string `json:"hash,omitempty"`
+	SimHash string `json:"simhash,omitempty"`
+
+	// Embeddings of the chunks of the content, when the index has an embedder
+	Vectors [][]float32 `json:"vectors,omitempty"`
+
+	// Highlighted snippets of the content returned by a search
+	// they are not part of the indexed document
+	Fragments []string `json:"-"`
+
+	// Paths of the copies of the document folded into it by a search
+	// collapsing the duplicates, they are not part of the indexed document
+	Copies []string `json:"-"`
+
+	// Analyzer of the content, the index default one if empty
+	Analyzer string `json:"-"`
+}
+
+// BleveType makes the documents of an analyzer use its mapping
+func (d *Document) BleveType() string {
+	return d.Analyzer
 }
 
 // Returns New Document object
-func NewDocument(path string, size int64, modTime string, extension string, content string) *Document {
+func NewDocument(path string, size int64, modTime time.Time, extension string, content string) *Document {
 
 	return &Document{
 		Path:      path,
+		Name:      filepath.Base(path),
 		Dir:       filepath.Dir(path),
 		Size:      size,
 		ModTime:   modTime,
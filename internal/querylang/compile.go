@@ -0,0 +1,258 @@
+package querylang
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Field searched by the words typed without a field
+type Field struct {
+	Name  string  // _all if empty
+	Boost float64 // multiplies the score of its matches, 1 if not set
+
+	analyzers []string
+}
+
+// Options of the compiled query
+type Options struct {
+	// Fields searched by words without a field, a match in any of them
+	// is enough so NOT excludes the word from all of them, _all if empty
+	Fields []Field
+	// ContentAnalyzers split the content of the documents in words,
+	// when there are several the words are looked for as each one splits them
+	ContentAnalyzers []string
+}
+
+// Compile turns the query into a Bleve one
+func (q *Query) Compile(opts Options) query.Query {
+	if len(opts.Fields) == 0 {
+		opts.Fields = []Field{{}}
+	}
+	return compile(q.root, opts)
+}
+
+func compile(n node, opts Options) query.Query {
+	switch t := n.(type) {
+	case *leaf:
+		return expand(t.field, opts, func(f Field) query.Query {
+			return compileLeaf(t, f)
+		})
+	case *nearNode:
+		return expand(t.field, opts, func(f Field) query.Query {
+			return eachAnalyzer(f, func(analyzer string) query.Query {
+				q := NewNearQuery(f.Name, t.terms, t.dist, f.Boost)
+				q.Analyzer = analyzer
+				return q
+			})
+		})
+	case *andNode:
+		b := bleve.NewBooleanQuery()
+		b.AddMust(compileAll(t.must, opts)...)
+		b.AddMustNot(compileAll(t.mustNot, opts)...)
+		return b
+	case *boolNode:
+		b := bleve.NewBooleanQuery()
+		b.AddMust(compileAll(t.must, opts)...)
+		b.AddShould(compileAll(t.should, opts)...)
+		b.AddMustNot(compileAll(t.mustNot, opts)...)
+		if len(t.must) == 0 && len(t.should) > 0 {
+			b.SetMinShould(1)
+		}
+		return b
+	}
+	return bleve.NewMatchNoneQuery()
+}
+
+func compileAll(nodes []node, opts Options) []query.Query {
+	if len(nodes) == 0 {
+		return nil
+	}
+	queries := make([]query.Query, 0, len(nodes))
+	for _, n := range nodes {
+		queries = append(queries, compile(n, opts))
+	}
+	return queries
+}
+
+// expand compiles a word for its field or for every default field
+func expand(field string, opts Options, compileField func(Field) query.Query) query.Query {
+	fields := opts.Fields
+	if field != "" {
+		fields = []Field{{Name: field}}
+	}
+	queries := make([]query.Query, 0, len(fields))
+	for _, f := range fields {
+		if isContent(f.Name) {
+			f.analyzers = opts.ContentAnalyzers
+		}
+		queries = append(queries, compileField(f))
+	}
+	if len(queries) == 1 {
+		return queries[0]
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// eachAnalyzer is the disjunction of the query analyzed by each analyzer
+// of the field, the query alone if the field has one
+func eachAnalyzer(f Field, compileAnalyzer func(analyzer string) query.Query) query.Query {
+	if len(f.analyzers) < 2 {
+		return compileAnalyzer("")
+	}
+	queries := make([]query.Query, 0, len(f.analyzers))
+	for _, analyzer := range f.analyzers {
+		queries = append(queries, compileAnalyzer(analyzer))
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// isContent reports if the field is analyzed like the content
+func isContent(field string) bool {
+	switch field {
+	case "", "content", "comment", "string":
+		return true
+	}
+	return false
+}
+
+type leafQuery interface {
+	query.FieldableQuery
+	query.BoostableQuery
+}
+
+func compileLeaf(l *leaf, f Field) query.Query {
+	if kind, ok := rangeFields[f.Name]; ok {
+		// Parse checked the value
+		q, err := compileRange(f.Name, l.text, kind)
+		if err != nil {
+			return bleve.NewMatchNoneQuery()
+		}
+		return q
+	}
+	var q leafQuery
+	switch l.kind {
+	case phraseLeaf:
+		return eachAnalyzer(f, func(analyzer string) query.Query {
+			pq := bleve.NewMatchPhraseQuery(l.text)
+			pq.Analyzer = analyzer
+			return boosted(pq, f)
+		})
+	case termLeaf:
+		// a word split in several, like getUserName, needs all of them
+		return eachAnalyzer(f, func(analyzer string) query.Query {
+			mq := bleve.NewMatchQuery(l.text)
+			mq.Analyzer = analyzer
+			mq.SetOperator(query.MatchQueryOperatorAnd)
+			return boosted(mq, f)
+		})
+	case fuzzyLeaf:
+		fq := bleve.NewFuzzyQuery(strings.ToLower(l.text))
+		fq.SetFuzziness(l.fuzz)
+		q = fq
+	case wildcardLeaf:
+		q = bleve.NewWildcardQuery(strings.ToLower(l.text))
+	default:
+		q = bleve.NewRegexpQuery(l.text)
+	}
+	return boosted(q, f)
+}
+
+// boosted sets the field and the boost of a word
+func boosted(q leafQuery, f Field) query.Query {
+	q.SetField(f.Name)
+	if f.Boost != 0 && f.Boost != 1 {
+		q.SetBoost(f.Boost)
+	}
+	return q
+}
+
+// Terms are the words looked for in the content, comments and strings
+// to highlight the preview
+// regular expressions and wildcards are returned between slashes
+func (q *Query) Terms() []string {
+	var terms []string
+	walk(q.root, func(n node, negated bool) {
+		if negated {
+			return
+		}
+		switch t := n.(type) {
+		case *leaf:
+			if !isContent(t.field) {
+				return
+			}
+			switch t.kind {
+			case regexLeaf:
+				terms = append(terms, "/"+t.text+"/")
+			case wildcardLeaf:
+				terms = append(terms, "/"+wildcardPattern(t.text)+"/")
+			default:
+				terms = append(terms, t.text)
+			}
+		case *nearNode:
+			if isContent(t.field) {
+				terms = append(terms, t.terms...)
+			}
+		}
+	})
+	return terms
+}
+
+// Word is a plain word of the query, where it was typed
+type Word struct {
+	Text  string
+	Field string // indexed field, empty for the default ones
+	Pos   int    // byte offset in the query
+}
+
+// Words are the plain words looked for, not the excluded ones
+// phrases, wildcards and fuzzy words are left out
+func (q *Query) Words() []Word {
+	var words []Word
+	walk(q.root, func(n node, negated bool) {
+		if t, ok := n.(*leaf); ok && !negated && t.kind == termLeaf {
+			words = append(words, Word{Text: t.text, Field: t.field, Pos: t.pos})
+		}
+	})
+	return words
+}
+
+// Symbols are the definitions looked for with symbol:
+// wildcards and regular expressions are returned between slashes
+func (q *Query) Symbols() []string {
+	var symbols []string
+	walk(q.root, func(n node, negated bool) {
+		t, ok := n.(*leaf)
+		if negated || !ok || t.field != "symbol" {
+			return
+		}
+		switch t.kind {
+		case regexLeaf:
+			symbols = append(symbols, "/"+t.text+"/")
+		case wildcardLeaf:
+			symbols = append(symbols, "/"+wildcardPattern(t.text)+"/")
+		default:
+			symbols = append(symbols, t.text)
+		}
+	})
+	return symbols
+}
+
+// wildcardPattern is the regular expression of a wildcard word
+func wildcardPattern(s string) string {
+	var sb strings.Builder
+	sb.WriteString(`\b`)
+	for _, part := range strings.SplitAfter(s, "") {
+		switch part {
+		case "*":
+			sb.WriteString(`\w*`)
+		case "?":
+			sb.WriteString(`\w`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(part))
+		}
+	}
+	return sb.String()
+}
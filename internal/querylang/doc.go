@@ -0,0 +1,50 @@
+// Package querylang is the query language of the GoSeek search box
+// and the command line. Queries are parsed into a small tree and
+// compiled to Bleve queries, see Syntax for what can be typed.
+package querylang
+
+// Syntax documents the query language, the GUI shows it in its help menu
+const Syntax = `Words
+  invoice 2024          documents with any of the words, the ones with more rank first
+  invoice AND 2024      both words, && works too
+  invoice OR bill       either word, || works too
+  +invoice 2024         invoice is required, 2024 only ranks higher
+  -draft  NOT draft     documents without the word
+
+Phrases and proximity
+  "annual report"       the words next to each other, in order
+  error NEAR/3 timeout  at most 3 words between them, in any order
+  error NEAR timeout    NEAR alone allows 5 words between them
+
+Fuzzy and patterns
+  recieve~              one typo away (insert, delete, replace or swap a letter)
+  recieve~2             up to two typos
+  inv*  colo?r          * matches any letters, ? exactly one
+  /colou?r/             a regular expression over the words
+
+Fields
+  name:report           the file name
+  path:2024             any folder or the name in the path
+  ext:pdf               the extension
+  dir:docs/old          the folder, relative to the indexed one
+  content:report        the content only
+  symbol:NewServer      a function, type or variable defined in a source file
+  comment:TODO          the comments of source files
+  string:"not found"    the string literals of source files
+  name:(report OR summary)   a field applies to a group too
+
+Metadata
+  author:alice          the author of a document or a photo
+  title:"Q3 plan"       the title of a document or a page
+  tag:finance           the keywords, tags of the Markdown front matter
+  camera:canon          the camera a photo was taken with
+  language:python       the language of a script or source file
+  owner:bob  perm:644   the owner and the permissions of the file
+  pages:>10             the page count, also pages:12, pages:<=3 or pages:10..20
+  created:2024-03       created that day, month or year, also >, >=, <, <=
+  created:2023..2024    created between two dates, both included
+
+Grouping
+  (invoice OR bill) AND 2024
+  NOT binds tighter than AND, AND tighter than OR.
+  Upper case AND, OR, NOT and NEAR are operators, lower case ones are words.`
@@ -0,0 +1,214 @@
+package querylang
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokWord             // term, wildcard or fuzzy term
+	tokPhrase           // "quoted words"
+	tokRegex            // /expression/
+	tokField            // name: before a value
+	tokAnd
+	tokOr
+	tokNot
+	tokNear
+	tokPlus
+	tokMinus
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // byte offset in the query
+	dist int // words allowed between NEAR operands
+}
+
+// DefaultNear is the distance of NEAR without /n
+const DefaultNear = 5
+
+// fieldAliases maps the fields typed in queries to the indexed ones
+var fieldAliases = map[string]string{
+	"name":      "filename",
+	"filename":  "filename",
+	"path":      "path",
+	"ext":       "extension",
+	"extension": "extension",
+	"dir":       "dir",
+	"content":   "content",
+	"symbol":    "symbol",
+	"comment":   "comment",
+	"string":    "string",
+	// metadata of the files
+	"author":   "author",
+	"title":    "title",
+	"created":  "created",
+	"pages":    "pages",
+	"camera":   "camera",
+	"keywords": "keywords",
+	"tag":      "keywords",
+	"tags":     "keywords",
+	"language": "language",
+	"lang":     "language",
+	"owner":    "owner",
+	"perm":     "perm",
+}
+
+type lexer struct {
+	input  string
+	pos    int
+	tokens []token
+}
+
+func lex(input string) ([]token, error) {
+	l := &lexer{input: input}
+	for {
+		l.skipSpaces()
+		if l.pos >= len(l.input) {
+			break
+		}
+		start := l.pos
+		switch c := l.input[l.pos]; {
+		case c == '(':
+			l.emit(tokLParen, "(", start)
+			l.pos++
+		case c == ')':
+			l.emit(tokRParen, ")", start)
+			l.pos++
+		case c == '"':
+			text, err := l.quoted('"', "missing closing quote")
+			if err != nil {
+				return nil, err
+			}
+			l.emit(tokPhrase, text, start)
+		case c == '/':
+			text, err := l.quoted('/', "missing closing / of the regular expression")
+			if err != nil {
+				return nil, err
+			}
+			l.emit(tokRegex, text, start)
+		case (c == '+' || c == '-') && l.pos+1 < len(l.input) && !isSpace(l.input[l.pos+1]):
+			if c == '+' {
+				l.emit(tokPlus, "+", start)
+			} else {
+				l.emit(tokMinus, "-", start)
+			}
+			l.pos++
+		case strings.HasPrefix(l.input[l.pos:], "&&"):
+			l.emit(tokAnd, "&&", start)
+			l.pos += 2
+		case strings.HasPrefix(l.input[l.pos:], "||"):
+			l.emit(tokOr, "||", start)
+			l.pos += 2
+		default:
+			if err := l.word(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	l.emit(tokEOF, "", len(l.input))
+	return l.tokens, nil
+}
+
+func (l *lexer) emit(kind tokenKind, text string, pos int) {
+	l.tokens = append(l.tokens, token{kind: kind, text: text, pos: pos})
+}
+
+func (l *lexer) skipSpaces() {
+	for l.pos < len(l.input) {
+		r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		l.pos += size
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// quoted reads up to the closing delim, a backslash escapes it
+func (l *lexer) quoted(delim byte, missing string) (string, error) {
+	start := l.pos
+	var sb strings.Builder
+	for i := start + 1; i < len(l.input); i++ {
+		c := l.input[i]
+		if c == '\\' && i+1 < len(l.input) && l.input[i+1] == delim {
+			sb.WriteByte(delim)
+			i++
+			continue
+		}
+		if c == delim {
+			l.pos = i + 1
+			return sb.String(), nil
+		}
+		sb.WriteByte(c)
+	}
+	return "", &Error{Query: l.input, Pos: start, Msg: missing}
+}
+
+// word reads a bare word, an operator or a field prefix
+func (l *lexer) word() error {
+	start := l.pos
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		if isSpace(c) || c == '(' || c == ')' || c == '"' {
+			break
+		}
+		l.pos++
+	}
+	text := l.input[start:l.pos]
+	switch {
+	case text == "AND":
+		l.emit(tokAnd, text, start)
+		return nil
+	case text == "OR":
+		l.emit(tokOr, text, start)
+		return nil
+	case text == "NOT":
+		l.emit(tokNot, text, start)
+		return nil
+	case text == "NEAR" || strings.HasPrefix(text, "NEAR/"):
+		dist := DefaultNear
+		if text != "NEAR" {
+			n, err := strconv.Atoi(text[len("NEAR/"):])
+			if err != nil || n < 0 {
+				return &Error{Query: l.input, Pos: start, Msg: "NEAR needs a distance in words, like NEAR/3"}
+			}
+			dist = n
+		}
+		l.tokens = append(l.tokens, token{kind: tokNear, text: text, pos: start, dist: dist})
+		return nil
+	}
+	if i := strings.IndexByte(text, ':'); i > 0 {
+		if _, ok := fieldAliases[strings.ToLower(text[:i])]; ok {
+			l.emit(tokField, strings.ToLower(text[:i]), start)
+			// the value follows the colon or is the next token
+			l.pos = start + i + 1
+			if l.pos < len(l.input) && l.input[l.pos] == '/' {
+				rest, err := l.quoted('/', "missing closing / of the regular expression")
+				if err != nil {
+					return err
+				}
+				l.emit(tokRegex, rest, start+i+1)
+				return nil
+			}
+			if i+1 < len(text) {
+				l.emit(tokWord, text[i+1:], start+i+1)
+			}
+			l.pos = start + len(text)
+			return nil
+		}
+	}
+	l.emit(tokWord, text, start)
+	return nil
+}
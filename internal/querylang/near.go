@@ -0,0 +1,157 @@
+package querylang
+
+import (
+	"context"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// NearQuery matches documents where Terms[i] is at most Distance[i]
+// words away from Terms[i+1], before or after it
+// Bleve phrase queries have no slop so the positions are checked here
+// on the term vectors of the field
+type NearQuery struct {
+	Terms    []string
+	Distance []int
+	FieldVal string
+	BoostVal float64
+	Analyzer string // the one of the field if empty
+}
+
+func NewNearQuery(field string, terms []string, distance []int, boost float64) *NearQuery {
+	return &NearQuery{Terms: terms, Distance: distance, FieldVal: field, BoostVal: boost}
+}
+
+func (q *NearQuery) SetField(f string) { q.FieldVal = f }
+func (q *NearQuery) Field() string     { return q.FieldVal }
+
+func (q *NearQuery) SetBoost(b float64) { q.BoostVal = b }
+func (q *NearQuery) Boost() float64     { return q.BoostVal }
+
+func (q *NearQuery) Searcher(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, options search.SearcherOptions) (search.Searcher, error) {
+	field := q.FieldVal
+	if field == "" {
+		field = m.DefaultSearchField()
+	}
+	terms, distance := q.analyze(m, field)
+	if len(terms) == 0 {
+		return searcher.NewMatchNoneSearcher(i)
+	}
+	var termQueries []query.Query
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		tq := query.NewTermQuery(term)
+		tq.SetField(field)
+		if q.BoostVal != 0 {
+			tq.SetBoost(q.BoostVal)
+		}
+		termQueries = append(termQueries, tq)
+	}
+	options.IncludeTermVectors = true
+	s, err := query.NewConjunctionQuery(termQueries).Searcher(ctx, i, m, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) == 1 {
+		return s, nil
+	}
+	return searcher.NewFilteringSearcher(ctx, s, func(d *search.DocumentMatch) bool {
+		return near(d, terms, distance)
+	}), nil
+}
+
+// analyze splits the words like the field does
+// the words of one operand must follow each other
+func (q *NearQuery) analyze(m mapping.IndexMapping, field string) ([]string, []int) {
+	name := q.Analyzer
+	if name == "" {
+		name = m.AnalyzerNameForPath(field)
+	}
+	analyzer := m.AnalyzerNamed(name)
+	var terms []string
+	var distance []int
+	gap := 0 // words allowed before the next operand
+	for i, text := range q.Terms {
+		var tokens []string
+		if analyzer == nil {
+			tokens = []string{text}
+		} else {
+			for _, tok := range analyzer.Analyze([]byte(text)) {
+				tokens = append(tokens, string(tok.Term))
+			}
+		}
+		for j, tok := range tokens {
+			if len(terms) > 0 {
+				if j == 0 {
+					distance = append(distance, gap)
+				} else {
+					distance = append(distance, 0)
+				}
+			}
+			terms = append(terms, tok)
+		}
+		if i < len(q.Distance) {
+			if len(tokens) == 0 && len(terms) > 0 {
+				// a dropped stop word widens the gap
+				gap += q.Distance[i] + 1
+			} else {
+				gap = q.Distance[i]
+			}
+		}
+	}
+	return terms, distance
+}
+
+// near checks the positions of the terms in the document
+// words of the _all field are reported in their own fields
+func near(d *search.DocumentMatch, terms []string, distance []int) bool {
+	fields := make(map[string]map[string][]uint64)
+	for _, ftl := range d.FieldTermLocations {
+		positions := fields[ftl.Field]
+		if positions == nil {
+			positions = make(map[string][]uint64)
+			fields[ftl.Field] = positions
+		}
+		positions[ftl.Term] = append(positions[ftl.Term], ftl.Location.Pos)
+	}
+	for _, positions := range fields {
+		if chain(positions, terms, distance) {
+			return true
+		}
+	}
+	return false
+}
+
+// chain looks for positions of the terms within their distances
+func chain(positions map[string][]uint64, terms []string, distance []int) bool {
+	var from func(i int, prev uint64) bool
+	from = func(i int, prev uint64) bool {
+		if i == len(terms) {
+			return true
+		}
+		for _, pos := range positions[terms[i]] {
+			if pos == prev {
+				continue
+			}
+			gap := max(pos, prev) - min(pos, prev) - 1
+			if gap <= uint64(distance[i-1]) && from(i+1, pos) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, pos := range positions[terms[0]] {
+		if from(1, pos) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package querylang
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+func newNearIndex(t *testing.T) bleve.Index {
+	t.Helper()
+	content := bleve.NewTextFieldMapping()
+	content.IncludeTermVectors = true
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("content", content)
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	index, err := bleve.NewMemOnly(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { index.Close() })
+	docs := map[string]string{
+		"fox":   "the quick brown fox jumps over the lazy dog",
+		"sleep": "the dog sleeps all day, lazy as ever",
+		"cat":   "a lazy cat and a dog",
+	}
+	for id, text := range docs {
+		if err := index.Index(id, map[string]string{"content": text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return index
+}
+
+func TestNear(t *testing.T) {
+	index := newNearIndex(t)
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"lazy NEAR/1 dog", []string{"fox"}},
+		{"lazy NEAR/3 dog", []string{"cat", "fox", "sleep"}},
+		// the words can come in any order
+		{"dog NEAR/0 lazy", []string{"fox"}},
+		// four words between fox and dog, "the" is a stop word but keeps its place
+		{"fox NEAR/3 dog", nil},
+		{"fox NEAR/4 dog", []string{"fox"}},
+		{"quick NEAR/1 fox NEAR/4 dog", []string{"fox"}},
+		{"quick NEAR/0 fox NEAR/4 dog", nil},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.query, err)
+		}
+		req := bleve.NewSearchRequest(q.Compile(Options{Fields: []Field{{Name: "content"}}}))
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		var got []string
+		for _, hit := range res.Hits {
+			got = append(got, hit.ID)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q found %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
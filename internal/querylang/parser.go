@@ -0,0 +1,420 @@
+package querylang
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error is a syntax error at a position of the query
+type Error struct {
+	Query string
+	Pos   int // byte offset of the problem
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column(), e.Msg)
+}
+
+// Column is the 1 based position of the problem in characters
+func (e *Error) Column() int {
+	return utf8.RuneCountInString(e.Query[:min(e.Pos, len(e.Query))]) + 1
+}
+
+// Caret shows the query with a ^ under the problem
+func (e *Error) Caret() string {
+	return e.Query + "\n" + strings.Repeat(" ", e.Column()-1) + "^"
+}
+
+type occur int
+
+const (
+	should occur = iota
+	must
+	mustNot
+)
+
+type leafKind int
+
+const (
+	termLeaf leafKind = iota
+	phraseLeaf
+	fuzzyLeaf
+	wildcardLeaf
+	regexLeaf
+)
+
+type node interface{}
+
+// leaf matches words of one field, the default one if field is empty
+type leaf struct {
+	kind  leafKind
+	field string
+	text  string
+	fuzz  int
+	pos   int // byte offset in the query
+}
+
+// nearNode matches words at most dist[i] words apart from the next one
+type nearNode struct {
+	field string
+	terms []string
+	dist  []int
+}
+
+// andNode needs all of must and none of mustNot
+type andNode struct {
+	must    []node
+	mustNot []node
+}
+
+// boolNode is a list of clauses, the should ones rank the results
+type boolNode struct {
+	must    []node
+	should  []node
+	mustNot []node
+}
+
+// Query is a parsed query ready to be compiled
+type Query struct {
+	root node
+}
+
+// Parse parses the query, errors are *Error
+func Parse(input string) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{input: input, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, p.errorf(p.peek(), "the query is empty")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %s", t.text)
+	}
+	if err := p.checkRanges(root); err != nil {
+		return nil, err
+	}
+	return &Query{root: root}, nil
+}
+
+// checkRanges reports the values of range fields that can not be compared
+// like pages:many, fields given to a group are only known once it is parsed
+func (p *parser) checkRanges(root node) error {
+	var err error
+	walk(root, func(n node, _ bool) {
+		var field string
+		var pos int
+		switch t := n.(type) {
+		case *leaf:
+			kind, ok := rangeFields[t.field]
+			if !ok || err != nil {
+				return
+			}
+			if t.kind == termLeaf {
+				if _, rerr := compileRange(t.field, t.text, kind); rerr != nil {
+					err = &Error{Query: p.input, Pos: t.pos, Msg: rerr.Error()}
+				}
+				return
+			}
+			field, pos = t.field, t.pos
+		case *nearNode:
+			if _, ok := rangeFields[t.field]; !ok {
+				return
+			}
+			field = t.field
+		default:
+			return
+		}
+		if err == nil {
+			err = &Error{Query: p.input, Pos: pos, Msg: field + ": compares a value, it can not have patterns, phrases or NEAR"}
+		}
+	})
+	return err
+}
+
+type parser struct {
+	input  string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{Query: p.input, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseOr reads clauses up to a closing parenthesis or the end
+// clauses next to each other are optional like OR ones
+func (p *parser) parseOr() (node, error) {
+	b := &boolNode{}
+	count := 0
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || t.kind == tokRParen {
+			break
+		}
+		if t.kind == tokOr {
+			if count == 0 {
+				return nil, p.errorf(t, "%s needs a term before it", t.text)
+			}
+			p.next()
+			if k := p.peek().kind; k == tokEOF || k == tokRParen || k == tokOr {
+				return nil, p.errorf(t, "%s needs a term after it", t.text)
+			}
+			continue
+		}
+		n, occ, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		switch occ {
+		case must:
+			b.must = append(b.must, n)
+		case mustNot:
+			b.mustNot = append(b.mustNot, n)
+		default:
+			b.should = append(b.should, n)
+		}
+		count++
+	}
+	if count == 1 && len(b.mustNot) == 0 {
+		if len(b.must) == 1 {
+			return b.must[0], nil
+		}
+		return b.should[0], nil
+	}
+	return b, nil
+}
+
+// parseAnd reads operands joined by AND
+func (p *parser) parseAnd() (node, occur, error) {
+	first, occ, err := p.parseUnary()
+	if err != nil {
+		return nil, should, err
+	}
+	if p.peek().kind != tokAnd {
+		return first, occ, nil
+	}
+	and := &andNode{}
+	add := func(n node, occ occur) {
+		if occ == mustNot {
+			and.mustNot = append(and.mustNot, n)
+		} else {
+			and.must = append(and.must, n)
+		}
+	}
+	add(first, occ)
+	for p.peek().kind == tokAnd {
+		op := p.next()
+		if k := p.peek().kind; k == tokEOF || k == tokRParen || k == tokOr || k == tokAnd {
+			return nil, should, p.errorf(op, "%s needs a term after it", op.text)
+		}
+		n, occ, err := p.parseUnary()
+		if err != nil {
+			return nil, should, err
+		}
+		add(n, occ)
+	}
+	return and, should, nil
+}
+
+func (p *parser) parseUnary() (node, occur, error) {
+	occ := should
+	switch t := p.peek(); t.kind {
+	case tokPlus:
+		occ = must
+	case tokMinus, tokNot:
+		occ = mustNot
+	}
+	if occ != should {
+		op := p.next()
+		switch next := p.peek(); next.kind {
+		case tokEOF, tokRParen:
+			return nil, should, p.errorf(op, "%s needs a term after it", op.text)
+		case tokPlus, tokMinus, tokNot:
+			return nil, should, p.errorf(next, "%s can not follow %s, it applies to a term", next.text, op.text)
+		}
+	}
+	n, err := p.parsePrimary("")
+	return n, occ, err
+}
+
+func (p *parser) parsePrimary(field string) (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		if p.peek().kind == tokRParen {
+			return nil, p.errorf(t, "empty parentheses")
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, p.errorf(t, "missing closing parenthesis")
+		}
+		if field != "" {
+			setField(n, field)
+		}
+		return n, nil
+	case tokField:
+		switch p.peek().kind {
+		case tokLParen, tokWord, tokPhrase, tokRegex:
+		default:
+			return nil, p.errorf(t, "%s: needs a value", t.text)
+		}
+		return p.parsePrimary(fieldAliases[t.text])
+	case tokPhrase:
+		if strings.TrimSpace(t.text) == "" {
+			return nil, p.errorf(t, "empty phrase")
+		}
+		if p.peek().kind == tokNear {
+			return nil, p.errorf(t, "NEAR takes plain words, not phrases")
+		}
+		return &leaf{kind: phraseLeaf, field: field, text: t.text, pos: t.pos}, nil
+	case tokRegex:
+		if t.text == "" {
+			return nil, p.errorf(t, "empty regular expression")
+		}
+		if err := p.checkRegex(t); err != nil {
+			return nil, err
+		}
+		return &leaf{kind: regexLeaf, field: field, text: t.text, pos: t.pos}, nil
+	case tokWord:
+		if p.peek().kind == tokNear {
+			return p.parseNear(t, field)
+		}
+		return p.wordLeaf(t, field)
+	case tokEOF:
+		return nil, p.errorf(t, "the query ends too early")
+	}
+	return nil, p.errorf(t, "%s needs a term before it", t.text)
+}
+
+// checkRegex reports the syntax errors of a regular expression
+// at the part of it Bleve would reject
+func (p *parser) checkRegex(t token) error {
+	_, err := syntax.Parse(t.text, syntax.Perl)
+	if err == nil {
+		return nil
+	}
+	pos := t.pos
+	msg := err.Error()
+	var serr *syntax.Error
+	if errors.As(err, &serr) {
+		msg = string(serr.Code)
+		// the regular expression starts after its opening /
+		if i := strings.Index(t.text, serr.Expr); i >= 0 {
+			pos = t.pos + 1 + i
+		}
+	}
+	return &Error{Query: p.input, Pos: pos, Msg: "invalid regular expression: " + msg}
+}
+
+// parseNear reads word NEAR/n word NEAR/m word...
+func (p *parser) parseNear(first token, field string) (node, error) {
+	n := &nearNode{field: field}
+	t := first
+	for {
+		if strings.ContainsAny(t.text, "*?~") {
+			return nil, p.errorf(t, "NEAR joins plain words")
+		}
+		n.terms = append(n.terms, t.text)
+		if p.peek().kind != tokNear {
+			return n, nil
+		}
+		op := p.next()
+		if t = p.next(); t.kind != tokWord {
+			return nil, p.errorf(op, "NEAR joins two words")
+		}
+		n.dist = append(n.dist, op.dist)
+	}
+}
+
+func (p *parser) wordLeaf(t token, field string) (node, error) {
+	text := t.text
+	if i := strings.LastIndexByte(text, '~'); i > 0 {
+		fuzz := 1
+		if i+1 < len(text) {
+			n, err := strconv.Atoi(text[i+1:])
+			if err != nil || n < 1 || n > 2 {
+				return nil, &Error{Query: p.input, Pos: t.pos + i, Msg: "fuzziness is ~1 or ~2"}
+			}
+			fuzz = n
+		}
+		if strings.ContainsAny(text[:i], "*?") {
+			return nil, &Error{Query: p.input, Pos: t.pos, Msg: "a fuzzy word can not have wildcards"}
+		}
+		return &leaf{kind: fuzzyLeaf, field: field, text: text[:i], fuzz: fuzz, pos: t.pos}, nil
+	}
+	if strings.ContainsAny(text, "*?") {
+		return &leaf{kind: wildcardLeaf, field: field, text: text, pos: t.pos}, nil
+	}
+	return &leaf{kind: termLeaf, field: field, text: text, pos: t.pos}, nil
+}
+
+// setField gives field to the leaves of a group
+func setField(n node, field string) {
+	walk(n, func(n node, _ bool) {
+		switch t := n.(type) {
+		case *leaf:
+			if t.field == "" {
+				t.field = field
+			}
+		case *nearNode:
+			if t.field == "" {
+				t.field = field
+			}
+		}
+	})
+}
+
+// walk calls fn on the leaves, negated tells if they are excluded
+func walk(n node, fn func(n node, negated bool)) {
+	var visit func(n node, negated bool)
+	visit = func(n node, negated bool) {
+		switch t := n.(type) {
+		case *andNode:
+			for _, sub := range t.must {
+				visit(sub, negated)
+			}
+			for _, sub := range t.mustNot {
+				visit(sub, !negated)
+			}
+		case *boolNode:
+			for _, sub := range t.must {
+				visit(sub, negated)
+			}
+			for _, sub := range t.should {
+				visit(sub, negated)
+			}
+			for _, sub := range t.mustNot {
+				visit(sub, !negated)
+			}
+		default:
+			fn(n, negated)
+		}
+	}
+	visit(n, false)
+}
@@ -0,0 +1,83 @@
+package querylang
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query  string
+		msg    string
+		column int
+	}{
+		{"", "the query is empty", 1},
+		{"(a", "missing closing parenthesis", 1},
+		{"()", "empty parentheses", 1},
+		{"a OR", "OR needs a term after it", 3},
+		{"OR a", "OR needs a term before it", 1},
+		{"a AND", "AND needs a term after it", 3},
+		{`"abc`, "missing closing quote", 1},
+		{`""`, "empty phrase", 1},
+		{"//", "empty regular expression", 1},
+		{"/a(/", "invalid regular expression: missing closing )", 2},
+		{"/ab[/", "invalid regular expression: missing closing ]", 4},
+		{"name:/[/", "invalid regular expression: missing closing ]", 7},
+		{"foo /x**/", "invalid regular expression: invalid nested repetition operator", 7},
+		{`"quick brown" NEAR/3 jumps`, "NEAR takes plain words, not phrases", 1},
+		{"quick NEAR/3", "NEAR joins two words", 7},
+		{"NEAR/3 fox", "NEAR/3 needs a term before it", 1},
+		{"NOT NOT a", "NOT can not follow NOT, it applies to a term", 5},
+		{"-NOT a", "NOT can not follow -, it applies to a term", 2},
+		{"NOT", "NOT needs a term after it", 1},
+		{"a~3", "fuzziness is ~1 or ~2", 2},
+		{"a*~1", "a fuzzy word can not have wildcards", 1},
+		{"pages:many", "pages: needs a number", 7},
+		{"created:2024-13", "created: needs a date", 9},
+		{`pages:"10"`, "pages: compares a value", 7},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		var qerr *Error
+		if !errors.As(err, &qerr) {
+			t.Errorf("Parse(%q) = %v, want a syntax error", tt.query, err)
+			continue
+		}
+		if !strings.Contains(qerr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) message = %q, want %q", tt.query, qerr.Msg, tt.msg)
+		}
+		if qerr.Column() != tt.column {
+			t.Errorf("Parse(%q) column = %d, want %d", tt.query, qerr.Column(), tt.column)
+		}
+	}
+}
+
+func TestParseValid(t *testing.T) {
+	for _, query := range []string{
+		"quick brown",
+		"quick AND (brown OR red) -slow",
+		`title:"Q3 plan"`,
+		"/ab+c/",
+		"name:/^re(port|cap)\\.txt$/",
+		"lazy NEAR/1 dog NEAR/2 sleeps",
+		"NOT slow",
+		"fuzzy~2 wild*",
+		"pages:>10 created:2023..2024",
+	} {
+		if _, err := Parse(query); err != nil {
+			t.Errorf("Parse(%q) = %v", query, err)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	q, err := Parse(`quick "brown fox" -slow name:report`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(q.Terms(), ",")
+	if want := "quick,brown fox"; got != want {
+		t.Errorf("Terms() = %q, want %q", got, want)
+	}
+}
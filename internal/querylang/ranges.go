@@ -0,0 +1,140 @@
+package querylang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+type rangeKind int
+
+const (
+	numberRange rangeKind = iota
+	dateRange
+)
+
+// rangeFields compare their values to the one typed
+// instead of matching words
+var rangeFields = map[string]rangeKind{
+	"pages":   numberRange,
+	"created": dateRange,
+}
+
+// comparisons of a range value, the longest first
+var comparisons = []string{">=", "<=", ">", "<"}
+
+// splitRange reads "10", ">10", "<=10" or "10..20" in bounds
+// op is the comparison of a single value, empty for a value or an interval
+func splitRange(text string) (op, from, to string) {
+	for _, c := range comparisons {
+		if v, ok := strings.CutPrefix(text, c); ok {
+			return c, v, ""
+		}
+	}
+	if from, to, ok := strings.Cut(text, ".."); ok {
+		return "", from, to
+	}
+	return "", text, text
+}
+
+// compileRange compiles the value of a range field
+func compileRange(field, text string, kind rangeKind) (query.Query, error) {
+	if kind == dateRange {
+		return dateQuery(field, text)
+	}
+	return numberQuery(field, text)
+}
+
+func numberQuery(field, text string) (query.Query, error) {
+	op, from, to := splitRange(text)
+	parse := func(s string) (*float64, error) {
+		if s == "" {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: needs a number like 10, >10, <=10 or 10..20", field)
+		}
+		return &v, nil
+	}
+	minV, err := parse(from)
+	if err != nil {
+		return nil, err
+	}
+	maxV, err := parse(to)
+	if err != nil {
+		return nil, err
+	}
+	inclusive, exclusive := true, false
+	minInc, maxInc := &inclusive, &inclusive
+	switch op {
+	case ">":
+		minInc = &exclusive
+	case "<":
+		minV, maxV, maxInc = nil, minV, &exclusive
+	case "<=":
+		minV, maxV = nil, minV
+	}
+	if minV == nil && maxV == nil {
+		return nil, fmt.Errorf("%s: needs a number like 10, >10, <=10 or 10..20", field)
+	}
+	q := bleve.NewNumericRangeInclusiveQuery(minV, maxV, minInc, maxInc)
+	q.SetField(field)
+	return q, nil
+}
+
+// dateLayouts are the periods a date can be typed as
+var dateLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+// period is the day, month or year of a typed date, end excluded
+func period(s string) (start, end time.Time, ok bool) {
+	for _, layout := range dateLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err != nil {
+			continue
+		}
+		switch layout {
+		case "2006":
+			return t, t.AddDate(1, 0, 0), true
+		case "2006-01":
+			return t, t.AddDate(0, 1, 0), true
+		}
+		return t, t.AddDate(0, 0, 1), true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func dateQuery(field, text string) (query.Query, error) {
+	bad := fmt.Errorf("%s: needs a date like 2024, 2024-03, >=2024-03-15 or 2023..2024", field)
+	op, from, to := splitRange(text)
+	fromStart, fromEnd, ok := period(from)
+	if !ok {
+		return nil, bad
+	}
+	// the zero time leaves the range open
+	var start, end time.Time
+	switch op {
+	case ">":
+		start = fromEnd
+	case ">=":
+		start = fromStart
+	case "<":
+		end = fromStart
+	case "<=":
+		end = fromEnd
+	default:
+		_, toEnd, ok := period(to)
+		if !ok {
+			return nil, bad
+		}
+		start, end = fromStart, toEnd
+	}
+	inclusive, exclusive := true, false
+	q := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &exclusive)
+	q.SetField(field)
+	return q, nil
+}
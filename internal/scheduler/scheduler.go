@@ -0,0 +1,254 @@
+package scheduler
+
+import (
+	"GoSeek/config"
+	"context"
+	"sync"
+)
+
+// Priority of a task, lower runs first
+type Priority int
+
+const (
+	Interactive Priority = iota // reindex asked by the user
+	Live                        // updates coming from the watcher
+	Bulk                        // initial scans
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case Interactive:
+		return "interactive"
+	case Live:
+		return "live"
+	}
+	return "bulk"
+}
+
+// Task is a unit of work of an index
+type Task struct {
+	Owner    string // index the task belongs to, used to share the pool fairly
+	Priority Priority
+	Run      func()
+}
+
+// maxQueuedBulk bounds the bulk tasks queued per index
+// so a walker can not queue a whole disk in memory
+const maxQueuedBulk = 256
+
+// Scheduler runs the tasks of all indexes on one pool of workers
+// higher priorities run first and inside a priority
+// indexes take turns so a big scan can not starve the others
+type Scheduler struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queues  [numPriorities]*fairQueue
+	running int
+	workers int
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+var (
+	defaultScheduler *Scheduler
+	defaultOnce      sync.Once
+)
+
+// Default returns the scheduler shared by all coordinators
+func Default() *Scheduler {
+	defaultOnce.Do(func() {
+		defaultScheduler = New(config.LoadGlobalConfig().NumWorkers)
+	})
+	return defaultScheduler
+}
+
+func New(workers int) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		workers: workers,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	for i := range s.queues {
+		s.queues[i] = newFairQueue()
+	}
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	return s
+}
+
+// Submit queues the task
+// bulk tasks block while their index already has enough queued work
+// it returns false if ctx is done or the scheduler is stopped
+func (s *Scheduler) Submit(ctx context.Context, t Task) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.Priority < 0 || t.Priority >= numPriorities {
+		t.Priority = Bulk
+	}
+	if t.Priority == Bulk {
+		stop := context.AfterFunc(ctx, func() {
+			s.mu.Lock()
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		})
+		defer stop()
+		for s.queues[Bulk].lenOf(t.Owner) >= maxQueuedBulk {
+			if ctx.Err() != nil || s.ctx.Err() != nil {
+				return false
+			}
+			s.cond.Wait()
+		}
+	}
+	if ctx.Err() != nil || s.ctx.Err() != nil {
+		return false
+	}
+	s.queues[t.Priority].push(t)
+	s.cond.Broadcast()
+	return true
+}
+
+// Drop removes the queued tasks of the owner and returns how many were removed
+func (s *Scheduler) Drop(owner string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, q := range s.queues {
+		n += q.drop(owner)
+	}
+	s.cond.Broadcast()
+	return n
+}
+
+// Stats of the queued and running tasks
+type Stats struct {
+	Workers int
+	Running int
+	Queued  [numPriorities]int
+}
+
+func (s *Scheduler) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := Stats{Workers: s.workers, Running: s.running}
+	for i, q := range s.queues {
+		st.Queued[i] = q.len()
+	}
+	return st
+}
+
+// Stop ends the workers after their current task
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.mu.Lock()
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) worker() {
+	defer s.wg.Done()
+	for {
+		s.mu.Lock()
+		task, ok := s.next()
+		for !ok {
+			if s.ctx.Err() != nil {
+				s.mu.Unlock()
+				return
+			}
+			s.cond.Wait()
+			task, ok = s.next()
+		}
+		s.running++
+		// a bulk slot may be free for a blocked walker
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		task.Run()
+
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}
+}
+
+// next pops the task to run, the caller holds the lock
+func (s *Scheduler) next() (Task, bool) {
+	for _, q := range s.queues {
+		if t, ok := q.pop(); ok {
+			return t, true
+		}
+	}
+	return Task{}, false
+}
+
+// fairQueue keeps a FIFO per owner and serves the owners round robin
+type fairQueue struct {
+	tasks map[string][]Task
+	order []string // owners with queued tasks in turn order
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{tasks: make(map[string][]Task)}
+}
+
+func (q *fairQueue) push(t Task) {
+	if len(q.tasks[t.Owner]) == 0 {
+		q.order = append(q.order, t.Owner)
+	}
+	q.tasks[t.Owner] = append(q.tasks[t.Owner], t)
+}
+
+func (q *fairQueue) pop() (Task, bool) {
+	if len(q.order) == 0 {
+		return Task{}, false
+	}
+	owner := q.order[0]
+	q.order = q.order[1:]
+	tasks := q.tasks[owner]
+	t := tasks[0]
+	tasks[0] = Task{}
+	if len(tasks) == 1 {
+		delete(q.tasks, owner)
+	} else {
+		q.tasks[owner] = tasks[1:]
+		// back of the line for its next task
+		q.order = append(q.order, owner)
+	}
+	return t, true
+}
+
+func (q *fairQueue) drop(owner string) int {
+	n := len(q.tasks[owner])
+	if n == 0 {
+		return 0
+	}
+	delete(q.tasks, owner)
+	for i, o := range q.order {
+		if o == owner {
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			break
+		}
+	}
+	return n
+}
+
+func (q *fairQueue) lenOf(owner string) int {
+	return len(q.tasks[owner])
+}
+
+func (q *fairQueue) len() int {
+	n := 0
+	for _, tasks := range q.tasks {
+		n += len(tasks)
+	}
+	return n
+}
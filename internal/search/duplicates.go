@@ -0,0 +1,212 @@
+package search
+
+import (
+	"GoSeek/config"
+	"GoSeek/internal/dedup"
+	"GoSeek/internal/indexer"
+	"GoSeek/internal/models"
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// DuplicatesRequest asks for the copies among the indexed files
+type DuplicatesRequest struct {
+	Folders        []string // like Request.Folders
+	ExcludeFolders []string // like Request.ExcludeFolders
+	// Near adds the files whose words differ a little, an edited copy
+	// or the same text in another format, see config NearDuplicateBits
+	Near bool
+}
+
+// DuplicateGroup is a set of copies of a file
+type DuplicateGroup struct {
+	Files  []models.Document // by path
+	Exact  bool              // every file has the same content
+	Wasted int64             // bytes of the files but the biggest one
+}
+
+// hashFields are read with the hits to tell the copies
+var hashFields = []string{"hash", "simhash"}
+
+// maxDupTerms bounds the hashes looked up by one query
+const maxDupTerms = 1024
+
+// Duplicates groups the copies among the files of all indexes,
+// the groups wasting the most bytes first
+// the hashes found in several documents are looked up in the dictionaries
+// of the indexes, so only the files having a copy are read
+func (e *Engine) Duplicates(req *DuplicatesRequest) ([]DuplicateGroup, error) {
+	bits := -1
+	if req.Near {
+		bits = config.LoadGlobalConfig().NearDuplicateBits
+	}
+	scopes := e.groupFolders(req.Folders, req.ExcludeFolders)
+	// documents by hash in every index of the scope
+	hashes := make(map[*indexer.BleveIndexer]map[string]uint64)
+	simHashes := make(map[*indexer.BleveIndexer]map[uint64]uint64)
+	total := make(map[string]uint64)
+	simTotal := make(map[uint64]uint64)
+	for index := range scopes {
+		terms, err := index.FieldTerms("hash")
+		if err != nil {
+			return nil, err
+		}
+		hashes[index] = make(map[string]uint64, len(terms))
+		for _, t := range terms {
+			hashes[index][t.Term] = t.Count
+			total[t.Term] += t.Count
+		}
+		if bits < 0 {
+			continue
+		}
+		terms, err = index.FieldTerms("simhash")
+		if err != nil {
+			return nil, err
+		}
+		simHashes[index] = make(map[uint64]uint64, len(terms))
+		for _, t := range terms {
+			if h, ok := dedup.Parse(t.Term); ok {
+				simHashes[index][h] = t.Count
+				simTotal[h] += t.Count
+			}
+		}
+	}
+	// the SimHashes of a cluster with several documents
+	var clusters map[uint64]uint64
+	if bits >= 0 {
+		clusters = dedup.Near(slices.Collect(maps.Keys(simTotal)), bits)
+		docs := make(map[uint64]uint64)
+		for h, root := range clusters {
+			docs[root] += simTotal[h]
+		}
+		for h, root := range clusters {
+			if docs[root] < 2 {
+				delete(clusters, h)
+			}
+		}
+	}
+	var files []models.Document
+	for index, dirs := range scopes {
+		var terms []query.Query
+		var count uint64
+		for hash, n := range hashes[index] {
+			if total[hash] > 1 {
+				terms = append(terms, termQuery("hash", hash))
+				count += n
+			}
+		}
+		for h, n := range simHashes[index] {
+			if _, ok := clusters[h]; ok {
+				terms = append(terms, termQuery("simhash", dedup.Format(h)))
+				count += n
+			}
+		}
+		for chunk := range slices.Chunk(terms, maxDupTerms) {
+			docs, err := duplicateFiles(index, chunk, dirs, count)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, docs...)
+		}
+	}
+	// a file of a chunk matching another chunk too is read twice
+	slices.SortFunc(files, func(a, b models.Document) int { return cmp.Compare(a.Path, b.Path) })
+	files = slices.CompactFunc(files, func(a, b models.Document) bool { return a.Path == b.Path })
+	var groups []DuplicateGroup
+	for _, group := range dedup.Group(files, copyKeys(clusters)) {
+		if len(group) < 2 {
+			continue // its copies are outside the scope
+		}
+		groups = append(groups, newDuplicateGroup(group))
+	}
+	slices.SortStableFunc(groups, func(a, b DuplicateGroup) int {
+		return cmp.Compare(b.Wasted, a.Wasted)
+	})
+	return groups, nil
+}
+
+func termQuery(field, term string) query.Query {
+	q := bleve.NewTermQuery(term)
+	q.SetField(field)
+	return q
+}
+
+// duplicateFiles are the files of the folders having one of the hashes
+// size bounds the number of files
+func duplicateFiles(index *indexer.BleveIndexer, terms []query.Query, dirs folderScope, size uint64) ([]models.Document, error) {
+	q, ok, err := scopeQuery(index, bleve.NewDisjunctionQuery(terms...), dirs, Filter{})
+	if err != nil || !ok {
+		return nil, err
+	}
+	searchRequest := bleve.NewSearchRequestOptions(q, int(size), 0, false)
+	searchRequest.Fields = append([]string{"name", "size", "mod_time", "extension"}, hashFields...)
+	hits, err := index.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	return hits.Docs, nil
+}
+
+// copyKeys are the keys dedup.Group tells the copies by,
+// the hash of the content and the cluster of its SimHash
+// clusters is nil when only the exact copies are grouped
+func copyKeys(clusters map[uint64]uint64) func(doc models.Document) []string {
+	return func(doc models.Document) []string {
+		var keys []string
+		if doc.Hash != "" {
+			keys = append(keys, "hash:"+doc.Hash)
+		}
+		if h, ok := dedup.Parse(doc.SimHash); ok && clusters != nil {
+			if root, ok := clusters[h]; ok {
+				keys = append(keys, "simhash:"+dedup.Format(root))
+			}
+		}
+		return keys
+	}
+}
+
+func newDuplicateGroup(files []models.Document) DuplicateGroup {
+	g := DuplicateGroup{Files: files, Exact: true}
+	var biggest int64
+	for _, f := range files {
+		g.Wasted += f.Size
+		biggest = max(biggest, f.Size)
+		if f.Hash == "" || f.Hash != files[0].Hash {
+			g.Exact = false
+		}
+	}
+	g.Wasted -= biggest
+	return g
+}
+
+// collapseFetch is how many more hits a collapsed search fetches
+const collapseFetch = 4
+
+// collapse keeps the first of the ranked hits of every group of copies
+// the near copies are grouped too when config NearDuplicateBits is set
+// folded is the number of hits left out
+func collapse(hits []models.Document) (collapsed []models.Document, folded int) {
+	var clusters map[uint64]uint64
+	if bits := config.LoadGlobalConfig().NearDuplicateBits; bits > 0 {
+		var simHashes []uint64
+		for _, hit := range hits {
+			if h, ok := dedup.Parse(hit.SimHash); ok {
+				simHashes = append(simHashes, h)
+			}
+		}
+		clusters = dedup.Near(simHashes, bits)
+	}
+	for _, group := range dedup.Group(hits, copyKeys(clusters)) {
+		best := group[0]
+		for _, dup := range group[1:] {
+			best.Copies = append(best.Copies, dup.Path)
+		}
+		collapsed = append(collapsed, best)
+		folded += len(group) - 1
+	}
+	return collapsed, folded
+}
@@ -0,0 +1,254 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+)
+
+// DateBucket is the width of the mod_time facet buckets
+type DateBucket string
+
+const (
+	ByYear  DateBucket = "year"
+	ByMonth DateBucket = "month"
+)
+
+// names of the facets, in the order they are returned
+const (
+	FacetExtension = "extension"
+	FacetDir       = "dir"
+	FacetModTime   = "mod_time"
+	FacetSize      = "size"
+	FacetAuthor    = "author"
+	FacetLanguage  = "language"
+	FacetOwner     = "owner"
+)
+
+const (
+	termFacetSize = 10 // extensions and folders shown per facet
+	dateBuckets   = 10 // years or months counted back from now
+)
+
+// Facet holds the counts of the results by one field
+type Facet struct {
+	Name    string
+	Buckets []FacetBucket
+	Missing int // results without the field
+	Other   int // results in terms beyond the listed ones
+}
+
+// FacetBucket is one value of a facet
+// Refine narrows a filter to the results of the bucket
+type FacetBucket struct {
+	Label  string
+	Count  int
+	refine func(*Filter)
+}
+
+func (b FacetBucket) Refine(f *Filter) {
+	if b.refine != nil {
+		b.refine(f)
+	}
+}
+
+// SizeRange is a size bucket, Max is excluded and 0 is unbounded
+type SizeRange struct {
+	Label    string
+	Min, Max int64
+}
+
+// SizeRanges are the buckets of the size facet
+var SizeRanges = []SizeRange{
+	{"< 10 KB", 0, 10 << 10},
+	{"10 KB - 1 MB", 10 << 10, 1 << 20},
+	{"1 MB - 100 MB", 1 << 20, 100 << 20},
+	{"> 100 MB", 100 << 20, 0},
+}
+
+// Apply sets the size bounds of the filter to the range
+func (r SizeRange) Apply(f *Filter) {
+	f.MinSize = r.Min
+	f.MaxSize = 0
+	if r.Max > 0 {
+		f.MaxSize = r.Max - 1
+	}
+}
+
+// dateRange is a mod_time bucket, End is excluded
+type dateRange struct {
+	Label      string
+	Start, End time.Time
+}
+
+// dateRanges returns the mod_time buckets ending with the current year or month
+func dateRanges(by DateBucket, now time.Time) []dateRange {
+	ranges := make([]dateRange, 0, dateBuckets)
+	for i := 0; i < dateBuckets; i++ {
+		var start, end time.Time
+		var label string
+		if by == ByMonth {
+			start = time.Date(now.Year(), now.Month()-time.Month(i), 1, 0, 0, 0, 0, now.Location())
+			end = start.AddDate(0, 1, 0)
+			label = start.Format("2006-01")
+		} else {
+			start = time.Date(now.Year()-i, time.January, 1, 0, 0, 0, 0, now.Location())
+			end = start.AddDate(1, 0, 0)
+			label = start.Format("2006")
+		}
+		ranges = append(ranges, dateRange{Label: label, Start: start, End: end})
+	}
+	return ranges
+}
+
+// facetRequests asks every index for the same facets
+func facetRequests(by DateBucket, now time.Time) bleve.FacetsRequest {
+	facets := bleve.FacetsRequest{
+		FacetExtension: bleve.NewFacetRequest("extension", termFacetSize),
+		FacetDir:       bleve.NewFacetRequest("dir", termFacetSize),
+	}
+	modTime := bleve.NewFacetRequest("mod_time", dateBuckets)
+	for _, r := range dateRanges(by, now) {
+		modTime.AddDateTimeRange(r.Label, r.Start, r.End)
+	}
+	facets[FacetModTime] = modTime
+	size := bleve.NewFacetRequest("size", len(SizeRanges))
+	for _, r := range SizeRanges {
+		var min, max *float64
+		if r.Min > 0 {
+			v := float64(r.Min)
+			min = &v
+		}
+		if r.Max > 0 {
+			v := float64(r.Max)
+			max = &v
+		}
+		size.AddNumericRange(r.Label, min, max)
+	}
+	facets[FacetSize] = size
+	facets[FacetAuthor] = bleve.NewFacetRequest("author_exact", termFacetSize)
+	facets[FacetLanguage] = bleve.NewFacetRequest("language", termFacetSize)
+	facets[FacetOwner] = bleve.NewFacetRequest("owner", termFacetSize)
+	return facets
+}
+
+// mergeFacets adds up the facet counts of all indexes
+// terms are ordered by count and ranges keep their natural order
+func mergeFacets(results []bsearch.FacetResults, by DateBucket, now time.Time) []Facet {
+	facets := []Facet{
+		mergeTerms(FacetExtension, results, func(term string) FacetBucket {
+			return FacetBucket{Label: "." + term, refine: func(f *Filter) {
+				f.Extensions = []string{term}
+			}}
+		}),
+		mergeTerms(FacetDir, results, func(term string) FacetBucket {
+			return FacetBucket{Label: term, refine: func(f *Filter) {
+				f.PathPrefixes = []string{term}
+			}}
+		}),
+	}
+
+	counts := make(map[string]int)
+	modTime := Facet{Name: FacetModTime}
+	for _, res := range results {
+		if fr, ok := res[FacetModTime]; ok {
+			modTime.Missing += fr.Missing
+			for _, r := range fr.DateRanges {
+				counts[r.Name] += r.Count
+			}
+		}
+	}
+	for _, r := range dateRanges(by, now) {
+		if counts[r.Label] == 0 {
+			continue
+		}
+		start, end := r.Start, r.End
+		modTime.Buckets = append(modTime.Buckets, FacetBucket{Label: r.Label, Count: counts[r.Label], refine: func(f *Filter) {
+			f.After, f.Before = start, end
+		}})
+	}
+	facets = append(facets, modTime)
+
+	clear(counts)
+	size := Facet{Name: FacetSize}
+	for _, res := range results {
+		if fr, ok := res[FacetSize]; ok {
+			size.Missing += fr.Missing
+			for _, r := range fr.NumericRanges {
+				counts[r.Name] += r.Count
+			}
+		}
+	}
+	for _, r := range SizeRanges {
+		if counts[r.Label] == 0 {
+			continue
+		}
+		size.Buckets = append(size.Buckets, FacetBucket{Label: r.Label, Count: counts[r.Label], refine: r.Apply})
+	}
+	facets = append(facets, size)
+	return append(facets,
+		mergeTerms(FacetAuthor, results, func(term string) FacetBucket {
+			return FacetBucket{Label: term, refine: func(f *Filter) {
+				f.Authors = []string{term}
+			}}
+		}),
+		mergeTerms(FacetLanguage, results, func(term string) FacetBucket {
+			return FacetBucket{Label: term, refine: func(f *Filter) {
+				f.Languages = []string{term}
+			}}
+		}),
+		mergeTerms(FacetOwner, results, func(term string) FacetBucket {
+			return FacetBucket{Label: term, refine: func(f *Filter) {
+				f.Owners = []string{term}
+			}}
+		}),
+	)
+}
+
+func mergeTerms(name string, results []bsearch.FacetResults, bucket func(term string) FacetBucket) Facet {
+	facet := Facet{Name: name}
+	counts := make(map[string]int)
+	for _, res := range results {
+		fr, ok := res[name]
+		if !ok {
+			continue
+		}
+		facet.Missing += fr.Missing
+		facet.Other += fr.Other
+		for _, t := range fr.Terms.Terms() {
+			// a file without the metadata has it empty
+			if t.Term == "" {
+				facet.Missing += t.Count
+				continue
+			}
+			counts[t.Term] += t.Count
+		}
+	}
+	terms := make([]string, 0, len(counts))
+	for term := range counts {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if counts[terms[i]] != counts[terms[j]] {
+			return counts[terms[i]] > counts[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	for i, term := range terms {
+		if i == termFacetSize {
+			facet.Other += counts[term]
+			continue
+		}
+		b := bucket(term)
+		b.Count = counts[term]
+		facet.Buckets = append(facet.Buckets, b)
+	}
+	return facet
+}
+
+func (b FacetBucket) String() string {
+	return fmt.Sprintf("%s (%d)", b.Label, b.Count)
+}
@@ -0,0 +1,243 @@
+package search
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Filter narrows the results of the text query
+// zero values do not filter
+type Filter struct {
+	MinSize int64 // bytes
+	MaxSize int64 // bytes
+
+	After  time.Time // modified at or after
+	Before time.Time // modified before
+
+	Extensions   []string // ".go" or "go"
+	PathPrefixes []string // absolute or relative to the parent of the indexed folder
+
+	// metadata of the files, any of the values, see package metadata
+	Authors   []string // whole names, as the author facet counts them
+	Languages []string
+	Owners    []string
+}
+
+func (f *Filter) Empty() bool {
+	return f.MinSize <= 0 && f.MaxSize <= 0 && f.After.IsZero() && f.Before.IsZero() &&
+		len(f.Extensions) == 0 && len(f.PathPrefixes) == 0 &&
+		len(f.Authors) == 0 && len(f.Languages) == 0 && len(f.Owners) == 0
+}
+
+// query builds the filter for an index stored relative to basePath
+// it returns false if no document of the index can match
+func (f *Filter) query(basePath string) (query.Query, bool) {
+	var queries []query.Query
+	if f.MinSize > 0 || f.MaxSize > 0 {
+		var min, max *float64
+		if f.MinSize > 0 {
+			v := float64(f.MinSize)
+			min = &v
+		}
+		if f.MaxSize > 0 {
+			v := float64(f.MaxSize)
+			max = &v
+		}
+		inclusive := true
+		q := bleve.NewNumericRangeInclusiveQuery(min, max, &inclusive, &inclusive)
+		q.SetField("size")
+		queries = append(queries, q)
+	}
+	if !f.After.IsZero() || !f.Before.IsZero() {
+		q := bleve.NewDateRangeQuery(f.After, f.Before)
+		q.SetField("mod_time")
+		queries = append(queries, q)
+	}
+	if len(f.Extensions) > 0 {
+		exts := make([]query.Query, 0, len(f.Extensions))
+		for _, ext := range f.Extensions {
+			// the extension field is analyzed so ".go" matches the term "go"
+			q := bleve.NewMatchQuery(ext)
+			q.SetField("extension")
+			exts = append(exts, q)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(exts...))
+	}
+	for _, meta := range []struct {
+		field  string
+		values []string
+		lower  bool // the languages are indexed lower cased
+	}{
+		{"author_exact", f.Authors, false},
+		{"language", f.Languages, true},
+		{"owner", f.Owners, false},
+	} {
+		if len(meta.values) == 0 {
+			continue
+		}
+		terms := make([]query.Query, 0, len(meta.values))
+		for _, v := range meta.values {
+			if meta.lower {
+				v = strings.ToLower(v)
+			}
+			q := bleve.NewTermQuery(v)
+			q.SetField(meta.field)
+			terms = append(terms, q)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(terms...))
+	}
+	if len(f.PathPrefixes) > 0 {
+		var dirs []query.Query
+		for _, prefix := range f.PathPrefixes {
+			dir, ok := relativeDir(prefix, basePath)
+			if !ok {
+				continue
+			}
+			dirs = append(dirs, dirPrefixQuery(dir))
+		}
+		if len(dirs) == 0 {
+			return nil, false
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(dirs...))
+	}
+	if len(queries) == 0 {
+		return nil, true
+	}
+	return bleve.NewConjunctionQuery(queries...), true
+}
+
+// relativeDir turns a path prefix into the dir field of the index
+func relativeDir(prefix, basePath string) (string, bool) {
+	prefix = filepath.Clean(prefix)
+	if !filepath.IsAbs(prefix) {
+		return prefix, true
+	}
+	rel, err := filepath.Rel(basePath, prefix)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// dirPrefixQuery matches the files in dir and in its sub folders
+func dirPrefixQuery(dir string) query.Query {
+	exact := bleve.NewTermQuery(dir)
+	exact.SetField("dir")
+	sub := bleve.NewPrefixQuery(dir + string(filepath.Separator))
+	sub.SetField("dir")
+	return bleve.NewDisjunctionQuery(exact, sub)
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"TB", 1 << 40},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+	{"B", 1},
+}
+
+// ParseSize reads sizes like "512", "10KB" or "1.5 MB"
+func ParseSize(input string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(input))
+	if s == "" {
+		return 0, nil
+	}
+	unit := 1.0
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			unit = u.bytes
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", input)
+	}
+	return int64(n * unit), nil
+}
+
+// FormatSize writes sizes the way ParseSize reads them, like "1.5 MB"
+func FormatSize(size int64) string {
+	units := sizeUnits[:4] // KB to TB
+	for i := len(units) - 1; i >= 0; i-- {
+		if float64(size) >= units[i].bytes {
+			return strconv.FormatFloat(float64(size)/units[i].bytes, 'f', 1, 64) + " " + units[i].suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + " B"
+}
+
+// ParseTime reads absolute dates like "2024-01-31"
+// and relative ones like "today", "yesterday", "7d" or "last 7 days"
+// relative times are counted back from now
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return time.Time{}, nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch s {
+	case "now":
+		return now, nil
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if t, ok := parseRelative(s, now); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// parseRelative reads "last 7 days", "3 weeks", "2h" or "1y"
+func parseRelative(s string, now time.Time) (time.Time, bool) {
+	s = strings.TrimSpace(strings.TrimPrefix(s, "last"))
+	s = strings.TrimSpace(strings.TrimSuffix(s, "ago"))
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n := 1 // "last week"
+	if i > 0 {
+		n, _ = strconv.Atoi(s[:i])
+	}
+	unit := strings.TrimSuffix(strings.TrimSpace(s[i:]), "s")
+	switch unit {
+	case "h", "hour":
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case "d", "day":
+		return now.AddDate(0, 0, -n), true
+	case "w", "week":
+		return now.AddDate(0, 0, -7*n), true
+	case "month":
+		return now.AddDate(0, -n, 0), true
+	case "y", "year":
+		return now.AddDate(-n, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// ParseList splits a comma separated list and drops the empty items
+func ParseList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+package search
+
+import (
+	"GoSeek/internal/indexer"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// corpusStats are the document counts of all the searched indexes
+// every index scores with them instead of its own, so a term has the
+// same idf everywhere and the scores of the indexes can be merged
+// Bleve's IndexAlias only shares them for BM25 and runs one query
+// on all indexes, while the queries here depend on the index
+type corpusStats struct {
+	readers []index.IndexReader
+	docs    uint64
+
+	mu sync.Mutex
+	df map[string]uint64 // documents having a term, by field and term
+}
+
+func newCorpusStats(indexes []*indexer.BleveIndexer) (*corpusStats, error) {
+	s := &corpusStats{df: make(map[string]uint64)}
+	for _, bi := range indexes {
+		idx, err := bi.Index.Advanced()
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		reader, err := idx.Reader()
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.readers = append(s.readers, reader)
+		docs, err := reader.DocCount()
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.docs += docs
+	}
+	return s, nil
+}
+
+func (s *corpusStats) Close() {
+	for _, reader := range s.readers {
+		reader.Close()
+	}
+}
+
+// docFreq is the number of documents of all indexes having the term
+func (s *corpusStats) docFreq(ctx context.Context, term []byte, field string) (uint64, error) {
+	key := field + "\x00" + string(term)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if df, ok := s.df[key]; ok {
+		return df, nil
+	}
+	var df uint64
+	for _, reader := range s.readers {
+		tfr, err := reader.TermFieldReader(ctx, term, field, false, false, false)
+		if err != nil {
+			return 0, err
+		}
+		df += tfr.Count()
+		tfr.Close()
+	}
+	s.df[key] = df
+	return df, nil
+}
+
+// globalQuery scores q with the counts of the whole corpus
+type globalQuery struct {
+	query.Query
+	stats *corpusStats
+}
+
+func (q *globalQuery) Searcher(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, options bsearch.SearcherOptions) (bsearch.Searcher, error) {
+	return q.Query.Searcher(ctx, &corpusReader{IndexReader: i, stats: q.stats}, m, options)
+}
+
+// Validate checks the wrapped query, bleve only validates the queries
+// implementing query.ValidatableQuery
+func (q *globalQuery) Validate() error {
+	if vq, ok := q.Query.(query.ValidatableQuery); ok {
+		return vq.Validate()
+	}
+	return nil
+}
+
+// corpusReader reads an index but counts the documents of the corpus
+type corpusReader struct {
+	index.IndexReader
+	stats *corpusStats
+}
+
+func (r *corpusReader) DocCount() (uint64, error) {
+	return r.stats.docs, nil
+}
+
+func (r *corpusReader) TermFieldReader(ctx context.Context, term []byte, field string, includeFreq, includeNorm, includeTermVectors bool) (index.TermFieldReader, error) {
+	tfr, err := r.IndexReader.TermFieldReader(ctx, term, field, includeFreq, includeNorm, includeTermVectors)
+	if err != nil {
+		return nil, err
+	}
+	df, err := r.stats.docFreq(ctx, term, field)
+	if err != nil {
+		tfr.Close()
+		return nil, err
+	}
+	return &corpusTermReader{TermFieldReader: tfr, df: df}, nil
+}
+
+// the searchers of regular expressions, wildcards and fuzzy words
+// look for these on the reader, they are passed through
+
+func (r *corpusReader) FieldDictRegexp(field string, regex string) (index.FieldDict, error) {
+	ir, ok := r.IndexReader.(index.IndexReaderRegexp)
+	if !ok {
+		return nil, fmt.Errorf("the index can not match regular expressions")
+	}
+	return ir.FieldDictRegexp(field, regex)
+}
+
+func (r *corpusReader) FieldDictRegexpAutomaton(field string, regex string) (index.FieldDict, index.RegexAutomaton, error) {
+	ir, ok := r.IndexReader.(index.IndexReaderRegexp)
+	if !ok {
+		return nil, nil, fmt.Errorf("the index can not match regular expressions")
+	}
+	return ir.FieldDictRegexpAutomaton(field, regex)
+}
+
+func (r *corpusReader) FieldDictFuzzy(field string, term string, fuzziness int, prefix string) (index.FieldDict, error) {
+	ir, ok := r.IndexReader.(index.IndexReaderFuzzy)
+	if !ok {
+		return nil, fmt.Errorf("the index can not look up close terms")
+	}
+	return ir.FieldDictFuzzy(field, term, fuzziness, prefix)
+}
+
+func (r *corpusReader) FieldDictFuzzyAutomaton(field string, term string, fuzziness int, prefix string) (index.FieldDict, index.FuzzyAutomaton, error) {
+	ir, ok := r.IndexReader.(index.IndexReaderFuzzy)
+	if !ok {
+		return nil, nil, fmt.Errorf("the index can not look up close terms")
+	}
+	return ir.FieldDictFuzzyAutomaton(field, term, fuzziness, prefix)
+}
+
+func (r *corpusReader) FieldDictContains(field string) (index.FieldDictContains, error) {
+	ir, ok := r.IndexReader.(index.IndexReaderContains)
+	if !ok {
+		return nil, fmt.Errorf("the index can not look up terms")
+	}
+	return ir.FieldDictContains(field)
+}
+
+// corpusTermReader reads the postings of an index
+// with the document frequency of the corpus
+type corpusTermReader struct {
+	index.TermFieldReader
+	df uint64
+}
+
+func (r *corpusTermReader) Count() uint64 {
+	return r.df
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"GoSeek/internal/grep"
+	"GoSeek/internal/indexer"
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// GrepRequest scans the files of the indexes with a regular expression
+type GrepRequest struct {
+	Pattern *grep.Pattern
+	Folders []string // restrict to these folders and their sub folders, empty searches everything
+	// ExcludeFolders leaves out these folders and their sub folders
+	ExcludeFolders []string
+	Filter         Filter // size, date, extension and path restrictions
+	MaxMatches     int    // stop after that many matches, no limit if 0
+}
+
+// GrepStats tells how much the index saved
+type GrepStats struct {
+	Candidates int // files the index could not rule out
+	Scanned    int // candidates read before the end or the limit
+	Files      int // files with a match
+	Matches    int
+}
+
+// candidatePage is the number of paths fetched from an index at once
+const candidatePage = 1000
+
+// Grep streams the lines matching the pattern to onMatch
+// the matches of a file come together and in order,
+// onMatch is never called concurrently and returns false to stop
+func (e *Engine) Grep(ctx context.Context, req *GrepRequest, onMatch func(grep.Match) bool) (GrepStats, error) {
+	var stats GrepStats
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				var matches []grep.Match
+				err := req.Pattern.ScanFile(ctx, path, func(m grep.Match) bool {
+					matches = append(matches, m)
+					return true
+				})
+				mu.Lock()
+				stats.Scanned++
+				if err == nil && len(matches) > 0 && ctx.Err() == nil {
+					stats.Files++
+					for _, m := range matches {
+						stats.Matches++
+						if !onMatch(m) || (req.MaxMatches > 0 && stats.Matches >= req.MaxMatches) {
+							cancel()
+							break
+						}
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := e.candidates(ctx, req, func(path string) {
+		mu.Lock()
+		stats.Candidates++
+		mu.Unlock()
+		select {
+		case paths <- path:
+		case <-ctx.Done():
+		}
+	})
+	close(paths)
+	wg.Wait()
+	return stats, err
+}
+
+// candidates lists the files of the scope the pattern can match
+func (e *Engine) candidates(ctx context.Context, req *GrepRequest, onPath func(string)) error {
+	for index, dirs := range e.groupFolders(req.Folders, req.ExcludeFolders) {
+		field, analyzers := "content", index.Options.ContentAnalyzers()
+		// stems and split identifiers are no pieces of the words in the files
+		if index.Options.HasContentWords() {
+			field, analyzers = indexer.ContentWordsField, []string{indexer.StandardAnalyzer}
+		}
+		text := req.Pattern.Query(index.Index.Mapping(), field, analyzers)
+		if text == nil {
+			text = bleve.NewMatchAllQuery()
+		}
+		q, ok, err := scopeQuery(index, text, dirs, req.Filter)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := pagePaths(ctx, index, q, onPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pagePaths(ctx context.Context, index *indexer.BleveIndexer, q query.Query, onPath func(string)) error {
+	for from := 0; ; from += candidatePage {
+		if ctx.Err() != nil {
+			return nil
+		}
+		searchRequest := bleve.NewSearchRequestOptions(q, candidatePage, from, false)
+		searchRequest.SortBy([]string{"_id"})
+		searchRequest.Score = "none"
+		hits, err := index.Search(searchRequest)
+		if err != nil {
+			return err
+		}
+		for _, doc := range hits.Docs {
+			onPath(doc.Path)
+		}
+		if len(hits.Docs) < candidatePage {
+			return nil
+		}
+	}
+}
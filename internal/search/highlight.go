@@ -0,0 +1,42 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// markers put around the matches by the bleve html highlighter
+const (
+	markStart = "<mark>"
+	markEnd   = "</mark>"
+)
+
+// FragmentPart is a piece of a snippet, Match is set on the matched terms
+type FragmentPart struct {
+	Text  string
+	Match bool
+}
+
+// SplitFragment cuts a highlighted snippet into plain and matched parts
+// and removes the html escaping of the highlighter
+func SplitFragment(fragment string) []FragmentPart {
+	var parts []FragmentPart
+	for fragment != "" {
+		start := strings.Index(fragment, markStart)
+		if start < 0 {
+			parts = append(parts, FragmentPart{Text: html.UnescapeString(fragment)})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, FragmentPart{Text: html.UnescapeString(fragment[:start])})
+		}
+		fragment = fragment[start+len(markStart):]
+		end := strings.Index(fragment, markEnd)
+		if end < 0 {
+			end = len(fragment)
+		}
+		parts = append(parts, FragmentPart{Text: html.UnescapeString(fragment[:end]), Match: true})
+		fragment = strings.TrimPrefix(fragment[end:], markEnd)
+	}
+	return parts
+}
@@ -0,0 +1,29 @@
+package search
+
+import (
+	"GoSeek/internal/locate"
+	"path/filepath"
+)
+
+// Locate finds files by name in all indexes, indexed or not
+// the matches have absolute paths, best first
+func (e *Engine) Locate(name string, mode locate.Mode, limit int) ([]locate.Match, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var matches []locate.Match
+	for _, index := range e.indexes {
+		basePath, err := index.BasePath()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range index.Names.Search(name, mode, limit) {
+			m.Path = filepath.Join(basePath, m.Path)
+			matches = append(matches, m)
+		}
+	}
+	locate.SortMatches(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
@@ -0,0 +1,35 @@
+package search
+
+import (
+	"GoSeek/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Metadata lists the metadata of a hit as "name: value" in the order
+// of the query fields, the unknown ones are left out
+// owner and permissions only when withFile is set
+func Metadata(doc models.Document, withFile bool) []string {
+	var lines []string
+	add := func(name, value string) {
+		if value != "" {
+			lines = append(lines, name+": "+value)
+		}
+	}
+	add("title", doc.Title)
+	add("author", doc.Author)
+	if doc.Created != nil {
+		add("created", doc.Created.Format("2006-01-02 15:04"))
+	}
+	if doc.Pages != nil {
+		add("pages", strconv.Itoa(*doc.Pages))
+	}
+	add("camera", doc.Camera)
+	add("keywords", strings.Join(doc.Keywords, ", "))
+	add("language", doc.Language)
+	if withFile {
+		add("owner", doc.Owner)
+		add("perm", doc.Perm)
+	}
+	return lines
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"GoSeek/internal/indexer"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// MaxLikeTerms is the number of significant terms a more like this search looks for
+const MaxLikeTerms = 25
+
+// MoreLikeThis finds the documents sharing the most significant terms
+// of the indexed file at path, the file itself left out
+// req gives the scope, the page and the order, its Query is not used
+func (e *Engine) MoreLikeThis(path string, req *Request) (*Result, error) {
+	like := *req
+	like.Like = path
+	return e.Search(&like)
+}
+
+// likeDoc is the document a more like this search starts from
+type likeDoc struct {
+	index *indexer.BleveIndexer
+	id    string
+	terms []indexer.WeightedTerm
+}
+
+// findLike picks the significant terms of the file at path
+// from the index holding it
+func (e *Engine) findLike(path string) (*likeDoc, error) {
+	for _, index := range e.indexList() {
+		basePath, err := index.BasePath()
+		if err != nil {
+			return nil, err
+		}
+		id, err := filepath.Rel(basePath, path)
+		if err != nil || strings.HasPrefix(id, "..") {
+			continue
+		}
+		if doc, err := index.Index.Document(id); err != nil || doc == nil {
+			continue
+		}
+		terms, err := index.SignificantTerms(id, MaxLikeTerms)
+		if err != nil {
+			return nil, err
+		}
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("%s has no terms found in other documents", path)
+		}
+		return &likeDoc{index: index, id: id, terms: terms}, nil
+	}
+	return nil, fmt.Errorf("%s is not indexed", path)
+}
+
+// query looks for the terms weighted by their significance
+// in the index of the document it is excluded
+func (l *likeDoc) query(index *indexer.BleveIndexer) query.Query {
+	terms := make([]query.Query, 0, len(l.terms))
+	for _, t := range l.terms {
+		q := bleve.NewTermQuery(t.Term)
+		q.SetField("content")
+		q.SetBoost(t.Weight)
+		terms = append(terms, q)
+	}
+	q := bleve.NewDisjunctionQuery(terms...)
+	if index != l.index {
+		return q
+	}
+	b := bleve.NewBooleanQuery()
+	b.AddMust(q)
+	b.AddMustNot(bleve.NewDocIDQuery([]string{l.id}))
+	return b
+}
+
+// words are the terms to highlight in the preview
+func (l *likeDoc) words() []string {
+	words := make([]string, 0, len(l.terms))
+	for _, t := range l.terms {
+		words = append(words, t.Term)
+	}
+	return words
+}
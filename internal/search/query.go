@@ -0,0 +1,44 @@
+package search
+
+import (
+	"GoSeek/internal/indexer"
+	"GoSeek/internal/querylang"
+	"path/filepath"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// CreateDirQuery matches the files of the folders and of their sub folders
+func CreateDirQuery(dirs []string) *query.DisjunctionQuery {
+	queries := make([]query.Query, 0, len(dirs))
+	for _, dir := range dirs {
+		queries = append(queries, dirPrefixQuery(dir))
+	}
+	dirQuery := bleve.NewDisjunctionQuery(queries...)
+	return dirQuery
+}
+
+// UnderFolder reports if path is folder or inside it
+// "docs" holds "docs/old" but not "docs-old"
+func UnderFolder(path, folder string) bool {
+	path, folder = filepath.Clean(path), filepath.Clean(folder)
+	return path == folder || strings.HasPrefix(path, folder+string(filepath.Separator))
+}
+
+// textQuery searches the content and the file names of the request
+// matches in names weigh nameBoost times the content ones
+// the content words are analyzed like the index does
+func textQuery(parsed *querylang.Query, namesOnly bool, nameBoost float64, index *indexer.BleveIndexer) query.Query {
+	opts := querylang.Options{ContentAnalyzers: index.Options.ContentAnalyzers()}
+	if namesOnly {
+		opts.Fields = []querylang.Field{{Name: "filename"}}
+	} else {
+		opts.Fields = []querylang.Field{
+			{}, // the content
+			{Name: "filename", Boost: nameBoost},
+		}
+	}
+	return parsed.Compile(opts)
+}
@@ -0,0 +1,406 @@
+package search
+
+import (
+	"GoSeek/config"
+	"GoSeek/internal/indexer"
+	"GoSeek/internal/models"
+	"GoSeek/internal/querylang"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Request of a search over the indexes
+type Request struct {
+	Query   string   // GoSeek query language, see querylang.Syntax
+	Folders []string // restrict to these folders and their sub folders, empty searches everything
+	// ExcludeFolders leaves out these folders and their sub folders
+	ExcludeFolders []string
+	Highlight      bool   // return snippets of the content around the matches
+	Filter         Filter // size, date, extension and path restrictions
+	NamesOnly      bool   // match the file names and not the content
+
+	// Similar ranks the documents by how close their meaning is to the
+	// query, the kNN of its embedding in the indexes having embeddings
+	Similar bool
+	// Blend adds the word matches of the query to the Similar ones,
+	// their scores weighted by Blend, 0 keeps only the nearest documents
+	Blend float64
+
+	// Like is the path of an indexed file, the search finds the documents
+	// like it instead of matching Query, see MoreLikeThis
+	Like string
+
+	// IDs restricts the search to these documents, all of them if empty
+	IDs []string
+
+	// Collapse shows every group of copies once, its best ranked file
+	// with the paths of the others in Copies, see Duplicates
+	Collapse bool
+
+	From int // offset of the first hit in the ranked results
+	Size int // hits per page, DefaultPageSize if not set
+
+	SortBy     SortField // score if not set
+	Descending bool      // ignored for score, the best scores come first
+
+	Facets     bool       // count the results by extension, folder, date and size
+	FacetDates DateBucket // width of the date buckets, ByYear if not set
+}
+
+// Result of a search over the indexes
+type Result struct {
+	Hits []models.Document // the requested page ranked over all indexes
+	// Total is the number of matching documents in all indexes
+	// a collapsed search only knows the copies among the hits it fetched,
+	// its Total is an estimate until the last page, use More to page
+	Total uint64
+	Terms []string // terms of the query, used to highlight the preview
+	// definitions searched with symbol:, the preview goes to them
+	Symbols []string
+	Facets  []Facet // counts over all matching documents when asked
+	// DidYouMean is the query with its words corrected when nothing
+	// matched, empty without a correction
+	DidYouMean string
+
+	collapsed bool // the hits are collapsed, more tells the next page
+	more      bool
+}
+
+// More reports if there are hits after this page
+func (r *Result) More(req *Request) bool {
+	if r.collapsed {
+		return r.more
+	}
+	return uint64(req.From+len(r.Hits)) < r.Total
+}
+
+// Engine searches the indexes it holds by folder name
+// the GUI and the command line share it
+type Engine struct {
+	mu      sync.RWMutex
+	indexes map[string]*indexer.BleveIndexer
+
+	// NameBoost weighs the matches in file names against the content ones
+	NameBoost float64
+}
+
+const DefaultPageSize = 100
+
+// metaFields are the stored metadata returned with the hits
+var metaFields = []string{"author", "title", "created", "pages", "camera", "keywords", "language", "owner", "perm"}
+
+func NewEngine() *Engine {
+	return &Engine{
+		indexes:   make(map[string]*indexer.BleveIndexer),
+		NameBoost: config.LoadGlobalConfig().NameBoost,
+	}
+}
+
+// Add makes the index searchable under the name of its folder
+func (e *Engine) Add(name string, index *indexer.BleveIndexer) {
+	if index == nil {
+		return
+	}
+	e.mu.Lock()
+	e.indexes[name] = index
+	e.mu.Unlock()
+}
+
+func (e *Engine) Remove(name string) {
+	e.mu.Lock()
+	delete(e.indexes, name)
+	e.mu.Unlock()
+}
+
+// Names returns the folders that can be searched
+func (e *Engine) Names() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, 0, len(e.indexes))
+	for name := range e.indexes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// indexList returns the indexes that can be searched
+func (e *Engine) indexList() []*indexer.BleveIndexer {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	indexes := make([]*indexer.BleveIndexer, 0, len(e.indexes))
+	for _, index := range e.indexes {
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
+
+// Search in all indexes found with specific query
+// every index returns its best From+Size hits in the requested order
+// then they are merged so the page is ranked over all indexes
+func (e *Engine) Search(req *Request) (*Result, error) {
+	res := &Result{}
+	var textFor func(index *indexer.BleveIndexer) query.Query
+	similarMode := req.Similar && req.Like == ""
+	if req.Like != "" {
+		like, err := e.findLike(req.Like)
+		if err != nil {
+			return nil, err
+		}
+		res.Terms = like.words()
+		textFor = like.query
+	} else {
+		parsed, err := querylang.Parse(req.Query)
+		keywords := !req.Similar || req.Blend > 0
+		if err != nil && keywords {
+			return nil, err
+		}
+		if keywords {
+			res.Terms, res.Symbols = parsed.Terms(), parsed.Symbols()
+		}
+		textFor = func(index *indexer.BleveIndexer) query.Query {
+			if !keywords {
+				return bleve.NewMatchNoneQuery()
+			}
+			return textQuery(parsed, req.NamesOnly, e.NameBoost, index)
+		}
+	}
+	size := req.Size
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	from := max(req.From, 0)
+	fetch := from + size
+	now := time.Now()
+	dateBucket := req.FacetDates
+	if dateBucket != ByMonth {
+		dateBucket = ByYear
+	}
+	// the query of every index in the scope
+	type scoped struct {
+		index *indexer.BleveIndexer
+		dirs  folderScope
+		q     query.Query
+	}
+	var scopes []scoped
+	for index, dirs := range e.groupFolders(req.Folders, req.ExcludeFolders) {
+		if similarMode && !index.Semantic() {
+			continue
+		}
+		text := textFor(index)
+		if len(req.IDs) > 0 {
+			text = bleve.NewConjunctionQuery(text, bleve.NewDocIDQuery(req.IDs))
+		}
+		q, ok, err := scopeQuery(index, text, dirs, req.Filter)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue // outside the path prefixes
+		}
+		scopes = append(scopes, scoped{index: index, dirs: dirs, q: q})
+	}
+	// the indexes score with the same counts so their hits can be ranked together
+	if len(scopes) > 1 {
+		indexes := make([]*indexer.BleveIndexer, len(scopes))
+		for i, s := range scopes {
+			indexes[i] = s.index
+		}
+		stats, err := newCorpusStats(indexes)
+		if err != nil {
+			return nil, err
+		}
+		defer stats.Close()
+		for i := range scopes {
+			scopes[i].q = &globalQuery{Query: scopes[i].q, stats: stats}
+		}
+	}
+	// search runs the query on every index for their best fetch hits
+	// exhausted reports if every matching document was fetched
+	search := func(fetch int) (hits []models.Document, total uint64, facets []bsearch.FacetResults, exhausted bool, err error) {
+		exhausted = true
+		for _, s := range scopes {
+			index, dirs, q := s.index, s.dirs, s.q
+			searchRequest := bleve.NewSearchRequestOptions(q, fetch, 0, false)
+			searchRequest.Fields = append([]string{"path", "name", "score", "size", "mod_time", "extension"}, metaFields...)
+			if req.Collapse {
+				searchRequest.Fields = append(searchRequest.Fields, hashFields...)
+			}
+			searchRequest.SortBy(req.bleveSort())
+			// indexes without stored content can not be highlighted
+			if req.Highlight && index.CanHighlight() {
+				searchRequest.Highlight = bleve.NewHighlight()
+				searchRequest.Highlight.AddField("content")
+			}
+			if req.Facets {
+				searchRequest.Facets = facetRequests(dateBucket, now)
+			}
+			if similarMode {
+				similar(index, searchRequest, req, dirs, fetch)
+			}
+			found, err := index.Search(searchRequest)
+			if err != nil {
+				return nil, 0, nil, false, err
+			}
+			hits = append(hits, found.Docs...)
+			total += found.Total
+			exhausted = exhausted && uint64(len(found.Docs)) >= found.Total
+			facets = append(facets, found.Facets)
+		}
+		req.mergeHits(hits)
+		return hits, total, facets, exhausted, nil
+	}
+	var facets []bsearch.FacetResults
+	if req.Collapse {
+		// the copies folded into a hit leave room for more of them,
+		// fetch more until the page and the first hit after it are known
+		// or nothing is left
+		fetch *= collapseFetch
+		for {
+			hits, total, found, exhausted, err := search(fetch)
+			if err != nil {
+				return nil, err
+			}
+			var folded int
+			res.Hits, folded = collapse(hits)
+			res.Total, facets = total-uint64(folded), found
+			res.collapsed, res.more = true, len(res.Hits) > from+size
+			if exhausted {
+				res.Total = uint64(len(res.Hits))
+			}
+			if exhausted || res.more {
+				break
+			}
+			fetch *= 2
+		}
+	} else {
+		var err error
+		res.Hits, res.Total, facets, _, err = search(fetch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if similarMode && len(scopes) == 0 {
+		return nil, ErrNoEmbeddings
+	}
+	if req.Facets {
+		res.Facets = mergeFacets(facets, dateBucket, now)
+	}
+	if res.Total == 0 && req.Like == "" && !req.Similar && len(req.IDs) == 0 {
+		// a failed correction leaves the empty result alone
+		res.DidYouMean, _, _ = e.DidYouMean(req.Query)
+	}
+	if from >= len(res.Hits) {
+		res.Hits = nil
+		return res, nil
+	}
+	res.Hits = res.Hits[from:min(from+size, len(res.Hits))]
+	return res, nil
+}
+
+// ErrNoEmbeddings is returned by a Similar search when no index
+// in the scope was created with an embedder
+var ErrNoEmbeddings = errors.New("no index in the scope has embeddings, create one with an embedder to search by meaning")
+
+// similar adds the k documents nearest to the query to the request
+// restricted to the folders and filter like the word matches
+// Bleve ignores the boost of compound queries, the nearest documents
+// are weighted by the inverse of the blend instead of the words by it
+func similar(index *indexer.BleveIndexer, searchRequest *bleve.SearchRequest, req *Request, dirs folderScope, k int) {
+	boost := 1.0
+	if req.Blend > 0 {
+		boost = 1 / req.Blend
+	} else {
+		searchRequest.Query = bleve.NewMatchNoneQuery()
+	}
+	var filter query.Query
+	if scope, _, _ := scopeQuery(index, bleve.NewMatchAllQuery(), dirs, req.Filter); scope != nil {
+		if _, all := scope.(*query.MatchAllQuery); !all {
+			filter = scope
+		}
+	}
+	index.AddSimilar(searchRequest, req.Query, k, boost, filter)
+}
+
+// scopeQuery restricts q to the folders and the filter
+// ok is false when nothing of the index is in the scope
+func scopeQuery(index *indexer.BleveIndexer, q query.Query, dirs folderScope, filter Filter) (query.Query, bool, error) {
+	queries := []query.Query{q}
+	if len(dirs.included) > 0 {
+		queries = append(queries, CreateDirQuery(dirs.included))
+	}
+	if !filter.Empty() {
+		basePath, err := index.BasePath()
+		if err != nil {
+			return nil, false, err
+		}
+		filterQuery, ok := filter.query(basePath)
+		if !ok {
+			return nil, false, nil
+		}
+		if filterQuery != nil {
+			queries = append(queries, filterQuery)
+		}
+	}
+	scoped := q
+	if len(queries) > 1 {
+		scoped = bleve.NewConjunctionQuery(queries...)
+	}
+	if len(dirs.excluded) == 0 {
+		return scoped, true, nil
+	}
+	b := bleve.NewBooleanQuery()
+	b.AddMust(scoped)
+	b.AddMustNot(CreateDirQuery(dirs.excluded))
+	return b, true, nil
+}
+
+// folderScope is the part of an index a search covers
+type folderScope struct {
+	included []string // folders with their sub folders, the whole index if empty
+	excluded []string // folders left out with their sub folders
+}
+
+// Group Folders according to their index
+// without folders every index is searched as a whole
+// a folder belongs to the index of its first path element,
+// "docs/old" to the index of docs and not to the one of docs-old
+func (e *Engine) groupFolders(folders, excluded []string) map[*indexer.BleveIndexer]folderScope {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	groups := make(map[*indexer.BleveIndexer]folderScope)
+	for name, index := range e.indexes {
+		var scope folderScope
+		whole := len(folders) == 0
+		for _, folder := range folders {
+			folder = filepath.Clean(folder)
+			switch {
+			case folder == name:
+				whole = true
+			case UnderFolder(folder, name):
+				scope.included = append(scope.included, folder)
+			}
+		}
+		if whole {
+			scope.included = nil
+		} else if len(scope.included) == 0 {
+			continue // no folder of the index
+		}
+		for _, folder := range excluded {
+			if UnderFolder(folder, name) {
+				scope.excluded = append(scope.excluded, filepath.Clean(folder))
+			}
+		}
+		if slices.Contains(scope.excluded, name) {
+			continue // the whole index is left out
+		}
+		groups[index] = scope
+	}
+	return groups
+}
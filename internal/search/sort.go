@@ -0,0 +1,74 @@
+package search
+
+import (
+	"GoSeek/internal/models"
+	"cmp"
+	"sort"
+)
+
+// SortField is what the results are ordered by
+type SortField string
+
+const (
+	SortScore   SortField = "score"
+	SortModTime SortField = "mod_time"
+	SortSize    SortField = "size"
+	SortName    SortField = "name"
+	SortPath    SortField = "path"
+)
+
+// SortFields lists the fields the results can be sorted by
+var SortFields = []SortField{SortScore, SortModTime, SortSize, SortName, SortPath}
+
+// bleveSort returns the sort order of the request for each index
+// ties are broken by path so pages do not overlap
+func (req *Request) bleveSort() []string {
+	field := "_score"
+	switch req.SortBy {
+	case SortModTime, SortSize, SortName:
+		field = string(req.SortBy)
+	case SortPath:
+		field = "_id"
+	}
+	if req.descending() {
+		field = "-" + field
+	}
+	if field == "_id" || field == "-_id" {
+		return []string{field}
+	}
+	return []string{field, "_id"}
+}
+
+// descending reports the direction of the sort, the best scores always come first
+func (req *Request) descending() bool {
+	if req.SortBy == "" || req.SortBy == SortScore {
+		return true
+	}
+	return req.Descending
+}
+
+// mergeHits orders the hits of all indexes the way each index ordered its own
+func (req *Request) mergeHits(hits []models.Document) {
+	desc := req.descending()
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := &hits[i], &hits[j]
+		if c := compareDocs(a, b, req.SortBy); c != 0 {
+			return (c < 0) != desc
+		}
+		return a.Path < b.Path
+	})
+}
+
+func compareDocs(a, b *models.Document, field SortField) int {
+	switch field {
+	case SortModTime:
+		return a.ModTime.Compare(b.ModTime)
+	case SortSize:
+		return cmp.Compare(a.Size, b.Size)
+	case SortName:
+		return cmp.Compare(a.Name, b.Name)
+	case SortPath:
+		return cmp.Compare(a.Path, b.Path)
+	}
+	return cmp.Compare(a.Score, b.Score)
+}
@@ -0,0 +1,192 @@
+package search
+
+import (
+	"GoSeek/internal/indexer"
+	"GoSeek/internal/querylang"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// suggestFields are the dictionaries of the completions and corrections
+var suggestFields = []string{"content", "filename"}
+
+// dictFields are the fields of the index to suggest words from
+// stemmed content would suggest stems, its unstemmed words are used
+func dictFields(index *indexer.BleveIndexer, fields []string) []string {
+	if !index.Options.Stemmed() {
+		return fields
+	}
+	words := make([]string, len(fields))
+	for i, field := range fields {
+		words[i] = field
+		if field == "content" {
+			words[i] = indexer.ContentWordsField
+		}
+	}
+	return words
+}
+
+// minCorrectLen is the shortest word worth correcting
+const minCorrectLen = 3
+
+// Complete lists the query with its last word completed by the indexed
+// words of the content and the file names, the words of the most
+// documents first
+// nothing is completed while the query ends with a space or an operator
+func (e *Engine) Complete(query string, limit int) ([]string, error) {
+	start := lastWordStart(query)
+	prefix := strings.ToLower(query[start:])
+	if prefix == "" || limit <= 0 {
+		return nil, nil
+	}
+	counts := make(map[string]uint64)
+	for _, index := range e.indexList() {
+		for _, field := range dictFields(index, suggestFields) {
+			terms, err := index.PrefixTerms(field, prefix)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range terms {
+				counts[t.Term] += t.Count
+			}
+		}
+	}
+	delete(counts, prefix)
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	completions := make([]string, 0, min(limit, len(words)))
+	for _, word := range words[:min(limit, len(words))] {
+		completions = append(completions, query[:start]+word)
+	}
+	return completions, nil
+}
+
+// lastWordStart is the offset of the word the query ends with
+func lastWordStart(query string) int {
+	start := len(query)
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(query[:start])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		start -= size
+	}
+	return start
+}
+
+// DidYouMean corrects the words of the query no document has with the
+// closest indexed ones, the word of the most documents between equally
+// close ones
+// ok is false when every word is indexed or has nothing close to it
+func (e *Engine) DidYouMean(query string) (corrected string, ok bool, err error) {
+	parsed, err := querylang.Parse(query)
+	if err != nil {
+		return "", false, err
+	}
+	words := parsed.Words()
+	sort.Slice(words, func(i, j int) bool { return words[i].Pos < words[j].Pos })
+	indexes := e.indexList()
+	var sb strings.Builder
+	last := 0
+	for _, w := range words {
+		fields := wordFields(w.Field)
+		if len(fields) == 0 || utf8.RuneCountInString(w.Text) < minCorrectLen {
+			continue
+		}
+		known, err := hasWord(indexes, fields, w.Text)
+		if err != nil {
+			return "", false, err
+		}
+		if known {
+			continue
+		}
+		best, found, err := closestWord(indexes, fields, strings.ToLower(w.Text))
+		if err != nil {
+			return "", false, err
+		}
+		if !found {
+			continue
+		}
+		sb.WriteString(query[last:w.Pos])
+		sb.WriteString(best)
+		last = w.Pos + len(w.Text)
+		ok = true
+	}
+	if !ok {
+		return "", false, nil
+	}
+	sb.WriteString(query[last:])
+	return sb.String(), true, nil
+}
+
+// wordFields are the dictionaries of a word typed in field
+func wordFields(field string) []string {
+	switch field {
+	case "":
+		return suggestFields
+	case "content", "filename":
+		return []string{field}
+	}
+	return nil
+}
+
+func hasWord(indexes []*indexer.BleveIndexer, fields []string, word string) (bool, error) {
+	for _, index := range indexes {
+		for _, field := range fields {
+			known, err := index.HasWord(field, word)
+			if err != nil || known {
+				return known, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// closestWord is the indexed word fewest edits away from word
+// short words allow one edit and longer ones two
+func closestWord(indexes []*indexer.BleveIndexer, fields []string, word string) (string, bool, error) {
+	edits := 2
+	if utf8.RuneCountInString(word) <= 5 {
+		edits = 1
+	}
+	best := indexer.DictTerm{Distance: edits + 1}
+	counts := make(map[string]uint64)
+	for _, index := range indexes {
+		for _, field := range dictFields(index, fields) {
+			terms, err := index.FuzzyTerms(field, word, edits)
+			if err != nil {
+				return "", false, err
+			}
+			for _, t := range terms {
+				if t.Distance > 0 && t.Distance <= best.Distance {
+					counts[t.Term] += t.Count
+					t.Count = counts[t.Term]
+					if closer(t, best) {
+						best = t
+					}
+				}
+			}
+		}
+	}
+	return best.Term, best.Term != "", nil
+}
+
+func closer(a, b indexer.DictTerm) bool {
+	if a.Distance != b.Distance {
+		return a.Distance < b.Distance
+	}
+	if a.Count != b.Count {
+		return a.Count > b.Count
+	}
+	return a.Term < b.Term
+}
@@ -0,0 +1,214 @@
+package watcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Handlers receive the events of the folders under a root
+type Handlers struct {
+	OnDelete func(string)
+	OnWrite  func(string)
+	OnCreate func(string)
+}
+
+// Mux shares one fsnotify watcher between all indexes
+// events are routed to the handlers of the deepest registered root
+type Mux struct {
+	mu       sync.RWMutex
+	watcher  *fsnotify.Watcher
+	handlers map[string]Handlers
+}
+
+var (
+	defaultMux     *Mux
+	defaultMuxErr  error
+	defaultMuxOnce sync.Once
+)
+
+// DefaultMux returns the watcher shared by all coordinators
+func DefaultMux() (*Mux, error) {
+	defaultMuxOnce.Do(func() {
+		defaultMux, defaultMuxErr = NewMux()
+	})
+	return defaultMux, defaultMuxErr
+}
+
+func NewMux() (*Mux, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	m := &Mux{
+		watcher:  w,
+		handlers: make(map[string]Handlers),
+	}
+	go m.run()
+	return m, nil
+}
+
+// Register routes the events under root to h
+func (m *Mux) Register(root string, h Handlers) {
+	m.mu.Lock()
+	m.handlers[filepath.Clean(root)] = h
+	m.mu.Unlock()
+}
+
+// Unregister stops the events under root and removes its watched folders
+func (m *Mux) Unregister(root string) {
+	root = filepath.Clean(root)
+	m.mu.Lock()
+	delete(m.handlers, root)
+	m.mu.Unlock()
+	for _, path := range m.watcher.WatchList() {
+		if _, ok := m.route(path); !ok && underRoot(path, root) {
+			m.watcher.Remove(path)
+		}
+	}
+}
+
+// Add watches the folder
+func (m *Mux) Add(path string) error {
+	return m.watcher.Add(path)
+}
+
+// route returns the deepest root holding path
+func (m *Mux) route(path string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	best := ""
+	for root := range m.handlers {
+		if underRoot(path, root) && len(root) > len(best) {
+			best = root
+		}
+	}
+	return best, best != ""
+}
+
+const (
+	// quietDelay is how long the events of a root wait for the next one
+	quietDelay = 10 * time.Second
+	// maxDelay bounds the wait of a root whose files never stop changing
+	maxDelay = 60 * time.Second
+)
+
+// pending holds the events of one root until its folders go quiet
+// an event replaces the one queued for the same path
+type pending struct {
+	events      []fsnotify.Event
+	index       map[string]int // position of the event of a path
+	first, last time.Time
+}
+
+func (p *pending) add(event fsnotify.Event, now time.Time) {
+	if i, ok := p.index[event.Name]; ok {
+		// a file written after being created is still a new one
+		if !(p.events[i].Has(fsnotify.Create) && event.Op == fsnotify.Write) {
+			p.events[i] = event
+		}
+	} else {
+		p.index[event.Name] = len(p.events)
+		p.events = append(p.events, event)
+	}
+	p.last = now
+}
+
+// due is when the events are handed to the handlers
+func (p *pending) due() time.Time {
+	quiet, limit := p.last.Add(quietDelay), p.first.Add(maxDelay)
+	if limit.Before(quiet) {
+		return limit
+	}
+	return quiet
+}
+
+// run debounces the events of every root on its own
+// so a busy folder does not hold the updates of the others
+func (m *Mux) run() {
+	queues := make(map[string]*pending)
+	timer := time.NewTimer(quietDelay)
+	defer timer.Stop()
+	// wake up for the root due first
+	schedule := func(now time.Time) {
+		var next time.Time
+		for _, p := range queues {
+			if due := p.due(); next.IsZero() || due.Before(next) {
+				next = due
+			}
+		}
+		if next.IsZero() {
+			timer.Stop()
+			return
+		}
+		timer.Reset(max(next.Sub(now), 0))
+	}
+	for {
+		select {
+		case event, ok := <-m.watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			root, ok := m.route(event.Name)
+			if !ok {
+				continue
+			}
+			now := time.Now()
+			p := queues[root]
+			if p == nil {
+				p = &pending{index: make(map[string]int), first: now}
+				queues[root] = p
+			}
+			p.add(event, now)
+			schedule(now)
+		case err, ok := <-m.watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Println(err)
+		case <-timer.C:
+			now := time.Now()
+			for root, p := range queues {
+				if p.due().After(now) {
+					continue
+				}
+				delete(queues, root)
+				m.dispatch(root, p.events)
+			}
+			schedule(now)
+		}
+	}
+}
+
+// dispatch hands the events of a root to its handlers
+// a root unregistered meanwhile drops them
+func (m *Mux) dispatch(root string, events []fsnotify.Event) {
+	m.mu.RLock()
+	h, ok := m.handlers[root]
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+	for _, event := range events {
+		switch {
+		case event.Has(fsnotify.Remove):
+			h.OnDelete(event.Name)
+		case event.Has(fsnotify.Create):
+			h.OnCreate(event.Name)
+		case event.Has(fsnotify.Write):
+			h.OnWrite(event.Name)
+		}
+	}
+}
+
+// underRoot reports if path is root or inside it
+func underRoot(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}